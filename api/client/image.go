@@ -0,0 +1,141 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/sara-nl/docker-1.9.1/api/types"
+	Cli "github.com/sara-nl/docker-1.9.1/cli"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+	flag "github.com/sara-nl/docker-1.9.1/pkg/mflag"
+	"github.com/sara-nl/docker-1.9.1/pkg/parsers"
+	"github.com/sara-nl/docker-1.9.1/pkg/units"
+	"github.com/sara-nl/docker-1.9.1/registry"
+)
+
+// CmdImage is the parent subcommand for all image commands
+//
+// Usage: docker image <COMMAND> <OPTS>
+func (cli *DockerCli) CmdImage(args ...string) error {
+	description := Cli.DockerCommands["image"].Description + "\n\nCommands:\n"
+	commands := [][]string{
+		{"diff", "Show the changes to the filesystem between two images"},
+		{"squash", "Merge the layers of an image into one"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker image COMMAND --help' for more information on a command"
+	cmd := Cli.Subcmd("image", []string{"[COMMAND]"}, description, false)
+
+	cmd.Require(flag.Exact, 0)
+	err := cmd.ParseFlags(args, true)
+	cmd.Usage()
+	return err
+}
+
+// CmdImageDiff shows the changes to the filesystem of an image that result
+// in the filesystem of another image.
+//
+// Each changed file is printed on a separate line, prefixed with a single
+// character that indicates the status of the file, like with docker diff.
+//
+// Usage: docker image diff [OPTIONS] IMAGE OTHER
+func (cli *DockerCli) CmdImageDiff(args ...string) error {
+	cmd := Cli.Subcmd("image diff", []string{"IMAGE OTHER"}, "Show the changes to the filesystem between two images", true)
+	flSize := cmd.Bool([]string{"s", "-size"}, false, "Display the change in size of the files")
+	cmd.Require(flag.Exact, 2)
+
+	cmd.ParseFlags(args, true)
+
+	v := url.Values{}
+	v.Set("other", cmd.Arg(1))
+	if *flSize {
+		v.Set("size", "1")
+	}
+
+	serverResp, err := cli.call("GET", "/images/"+cmd.Arg(0)+"/diff?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer serverResp.body.Close()
+
+	changes := []types.ImageChange{}
+	if err := json.NewDecoder(serverResp.body).Decode(&changes); err != nil {
+		return err
+	}
+
+	var total int64
+	for _, change := range changes {
+		var kind string
+		switch archive.ChangeType(change.Kind) {
+		case archive.ChangeModify:
+			kind = "C"
+		case archive.ChangeAdd:
+			kind = "A"
+		case archive.ChangeDelete:
+			kind = "D"
+		}
+		if !*flSize {
+			fmt.Fprintf(cli.out, "%s %s\n", kind, change.Path)
+			continue
+		}
+		fmt.Fprintf(cli.out, "%s %s %s\n", kind, change.Path, humanSizeDelta(change.SizeDelta))
+		total += change.SizeDelta
+	}
+	if *flSize {
+		fmt.Fprintf(cli.out, "Total: %s\n", humanSizeDelta(total))
+	}
+
+	return nil
+}
+
+// humanSizeDelta returns a human-readable difference in size, with its sign.
+func humanSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + units.HumanSize(float64(-delta))
+	}
+	return "+" + units.HumanSize(float64(delta))
+}
+
+// CmdImageSquash merges the layers of an image on top of one of its parents
+// into a single layer.
+//
+// Usage: docker image squash [OPTIONS] IMAGE
+func (cli *DockerCli) CmdImageSquash(args ...string) error {
+	cmd := Cli.Subcmd("image squash", []string{"IMAGE"}, "Merge the layers of an image into one", true)
+	flFrom := cmd.String([]string{"-from"}, "", "Only merge the layers on top of this parent image")
+	flTag := cmd.String([]string{"t", "-tag"}, "", "Repository name (and optionally a tag) for the resulting image")
+	cmd.Require(flag.Exact, 1)
+
+	cmd.ParseFlags(args, true)
+
+	repository, tag := parsers.ParseRepositoryTag(*flTag)
+	if repository != "" {
+		if err := registry.ValidateRepositoryName(repository); err != nil {
+			return err
+		}
+	}
+
+	v := url.Values{}
+	v.Set("from", *flFrom)
+	v.Set("repo", repository)
+	v.Set("tag", tag)
+
+	serverResp, err := cli.call("POST", "/images/"+cmd.Arg(0)+"/squash?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer serverResp.body.Close()
+
+	var response types.ImageSquashResponse
+	if err := json.NewDecoder(serverResp.body).Decode(&response); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.out, response.ID)
+	return nil
+}
@@ -27,6 +27,7 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 	all := cmd.Bool([]string{"a", "-all"}, false, "Show all images (default hides intermediate images)")
 	noTrunc := cmd.Bool([]string{"#notrunc", "-no-trunc"}, false, "Don't truncate output")
 	showDigests := cmd.Bool([]string{"-digests"}, false, "Show digests")
+	showUsage := cmd.Bool([]string{"-usage"}, false, "Show when images were last used and pulled")
 
 	flFilter := opts.NewListOpts(nil)
 	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
@@ -77,11 +78,14 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 
 	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
 	if !*quiet {
+		header := "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tVIRTUAL SIZE"
 		if *showDigests {
-			fmt.Fprintln(w, "REPOSITORY\tTAG\tDIGEST\tIMAGE ID\tCREATED\tVIRTUAL SIZE")
-		} else {
-			fmt.Fprintln(w, "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tVIRTUAL SIZE")
+			header = "REPOSITORY\tTAG\tDIGEST\tIMAGE ID\tCREATED\tVIRTUAL SIZE"
 		}
+		if *showUsage {
+			header += "\tLAST USED\tLAST PULLED\tUSES"
+		}
+		fmt.Fprintln(w, header)
 	}
 
 	for _, image := range images {
@@ -113,10 +117,14 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 
 			if !*quiet {
 				if *showDigests {
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\n", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
 				} else {
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+				}
+				if *showUsage {
+					fmt.Fprintf(w, "\t%s\t%s\t%d", humanTimeAgo(image.LastUsed), humanTimeAgo(image.LastPulled), image.UseCount)
 				}
+				fmt.Fprintln(w)
 			} else {
 				fmt.Fprintln(w, ID)
 			}
@@ -128,3 +136,12 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 	}
 	return nil
 }
+
+// humanTimeAgo formats a unix timestamp relative to now, or "Never" for a
+// zero timestamp.
+func humanTimeAgo(timestamp int64) string {
+	if timestamp == 0 {
+		return "Never"
+	}
+	return units.HumanDuration(time.Now().UTC().Sub(time.Unix(timestamp, 0))) + " ago"
+}
@@ -17,6 +17,9 @@ import (
 func (cli *DockerCli) CmdSave(args ...string) error {
 	cmd := Cli.Subcmd("save", []string{"IMAGE [IMAGE...]"}, Cli.DockerCommands["save"].Description+" (streamed to STDOUT by default)", true)
 	outfile := cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
+	excludeBase := cmd.String([]string{"-exclude-base"}, "", "Leave out the layers of this base image")
+	format := cmd.String([]string{"-format"}, "legacy", "Archive format, legacy or bundle")
+	sign := cmd.Bool([]string{"-sign"}, false, "Sign the manifests of a bundle with the daemon's key")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
@@ -44,6 +47,15 @@ func (cli *DockerCli) CmdSave(args ...string) error {
 	for _, arg := range cmd.Args() {
 		v.Add("names", arg)
 	}
+	if *excludeBase != "" {
+		v.Set("exclude-base", *excludeBase)
+	}
+	if *format != "legacy" {
+		v.Set("format", *format)
+	}
+	if *sign {
+		v.Set("sign", "1")
+	}
 	if _, err := cli.stream("GET", "/images/get?"+v.Encode(), sopts); err != nil {
 		return err
 	}
@@ -31,6 +31,7 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 	trusted := cmd.Bool([]string{"#t", "#trusted", "#-trusted"}, false, "Only show trusted builds")
 	automated := cmd.Bool([]string{"-automated"}, false, "Only show automated builds")
 	stars := cmd.Uint([]string{"s", "#stars", "-stars"}, 0, "Only displays with at least x stars")
+	registryName := cmd.String([]string{"-registry"}, "", "Search this registry instead of the Docker Hub")
 	cmd.Require(flag.Exact, 1)
 
 	cmd.ParseFlags(args, true)
@@ -38,9 +39,15 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 	name := cmd.Arg(0)
 	v := url.Values{}
 	v.Set("term", name)
+	if *registryName != "" {
+		v.Set("registry", *registryName)
+	}
 
 	// Resolve the Repository name from fqn to hostname + name
 	taglessRemote, _ := parsers.ParseRepositoryTag(name)
+	if *registryName != "" {
+		taglessRemote = *registryName + "/" + taglessRemote
+	}
 
 	indexInfo, err := registry.ParseIndexInfo(taglessRemote)
 	if err != nil {
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	Cli "github.com/sara-nl/docker-1.9.1/cli"
+	flag "github.com/sara-nl/docker-1.9.1/pkg/mflag"
+	"github.com/sara-nl/docker-1.9.1/registry"
+)
+
+// CmdTags lists the tags of a repository in its registry.
+//
+// Usage: docker tags [OPTIONS] [REGISTRYHOST/][USERNAME/]NAME
+func (cli *DockerCli) CmdTags(args ...string) error {
+	cmd := Cli.Subcmd("tags", []string{"[REGISTRYHOST/][USERNAME/]NAME"}, Cli.DockerCommands["tags"].Description, true)
+	cmd.Require(flag.Exact, 1)
+
+	cmd.ParseFlags(args, true)
+
+	name := cmd.Arg(0)
+	if err := registry.ValidateRepositoryName(name); err != nil {
+		return err
+	}
+	indexInfo, err := registry.ParseIndexInfo(name)
+	if err != nil {
+		return err
+	}
+
+	rdr, _, err := cli.clientRequestAttemptLogin("GET", "/images/"+name+"/tags", nil, nil, indexInfo, "tags")
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var tags []string
+	if err := json.NewDecoder(rdr).Decode(&tags); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		fmt.Fprintln(cli.out, tag)
+	}
+	return nil
+}
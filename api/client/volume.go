@@ -3,9 +3,11 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
+	"os"
 	"text/tabwriter"
 	"text/template"
 
@@ -14,6 +16,8 @@ import (
 	"github.com/sara-nl/docker-1.9.1/opts"
 	flag "github.com/sara-nl/docker-1.9.1/pkg/mflag"
 	"github.com/sara-nl/docker-1.9.1/pkg/parsers/filters"
+	"github.com/sara-nl/docker-1.9.1/pkg/units"
+	"github.com/sara-nl/docker-1.9.1/runconfig"
 )
 
 // CmdVolume is the parent subcommand for all volume commands
@@ -23,9 +27,13 @@ func (cli *DockerCli) CmdVolume(args ...string) error {
 	description := Cli.DockerCommands["volume"].Description + "\n\nCommands:\n"
 	commands := [][]string{
 		{"create", "Create a volume"},
+		{"export", "Stream the content of a volume as a tar archive"},
+		{"import", "Create a volume from a tar archive"},
 		{"inspect", "Return low-level information on a volume"},
 		{"ls", "List volumes"},
+		{"resize", "Grow a volume"},
 		{"rm", "Remove a volume"},
+		{"snapshot", "Manage snapshots of a volume"},
 	}
 
 	for _, cmd := range commands {
@@ -48,8 +56,9 @@ func (cli *DockerCli) CmdVolumeLs(args ...string) error {
 	cmd := Cli.Subcmd("volume ls", nil, "List volumes", true)
 
 	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display volume names")
+	size := cmd.Bool([]string{"s", "-size"}, false, "Display the space used by the volumes")
 	flFilter := opts.NewListOpts(nil)
-	cmd.Var(&flFilter, []string{"f", "-filter"}, "Provide filter values (i.e. 'dangling=true')")
+	cmd.Var(&flFilter, []string{"f", "-filter"}, "Provide filter values (i.e. 'dangling=true', 'label=project=tardis')")
 
 	cmd.Require(flag.Exact, 0)
 	cmd.ParseFlags(args, true)
@@ -71,6 +80,9 @@ func (cli *DockerCli) CmdVolumeLs(args ...string) error {
 		}
 		v.Set("filters", filterJSON)
 	}
+	if *size && !*quiet {
+		v.Set("size", "1")
+	}
 
 	resp, err := cli.call("GET", "/volumes?"+v.Encode(), nil, nil)
 	if err != nil {
@@ -85,6 +97,9 @@ func (cli *DockerCli) CmdVolumeLs(args ...string) error {
 	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
 	if !*quiet {
 		fmt.Fprintf(w, "DRIVER \tVOLUME NAME")
+		if *size {
+			fmt.Fprintf(w, "\tSIZE\tREFERENCES")
+		}
 		fmt.Fprintf(w, "\n")
 	}
 
@@ -93,7 +108,15 @@ func (cli *DockerCli) CmdVolumeLs(args ...string) error {
 			fmt.Fprintln(w, vol.Name)
 			continue
 		}
-		fmt.Fprintf(w, "%s\t%s\n", vol.Driver, vol.Name)
+		fmt.Fprintf(w, "%s\t%s", vol.Driver, vol.Name)
+		if *size && vol.Usage != nil {
+			sizeStr := "N/A"
+			if vol.Usage.Size >= 0 {
+				sizeStr = units.HumanSize(float64(vol.Usage.Size))
+			}
+			fmt.Fprintf(w, "\t%s\t%d", sizeStr, vol.Usage.RefCount)
+		}
+		fmt.Fprintf(w, "\n")
 	}
 	w.Flush()
 	return nil
@@ -183,12 +206,16 @@ func (cli *DockerCli) CmdVolumeCreate(args ...string) error {
 	flDriverOpts := opts.NewMapOpts(nil, nil)
 	cmd.Var(flDriverOpts, []string{"o", "-opt"}, "Set driver specific options")
 
+	flLabels := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flLabels, []string{"-label"}, "Set metadata for a volume")
+
 	cmd.Require(flag.Exact, 0)
 	cmd.ParseFlags(args, true)
 
 	volReq := &types.VolumeCreateRequest{
 		Driver:     *flDriver,
 		DriverOpts: flDriverOpts.GetAll(),
+		Labels:     runconfig.ConvertKVStringsToMap(flLabels.GetAll()),
 	}
 
 	if *flName != "" {
@@ -232,3 +259,273 @@ func (cli *DockerCli) CmdVolumeRm(args ...string) error {
 	}
 	return nil
 }
+
+// CmdVolumeExport exports the content of a volume as a tar archive.
+//
+// The tar archive is streamed to STDOUT by default or written to a file.
+//
+// Usage: docker volume export [OPTIONS] VOLUME
+func (cli *DockerCli) CmdVolumeExport(args ...string) error {
+	cmd := Cli.Subcmd("volume export", []string{"VOLUME"}, "Stream the content of a volume as a tar archive", true)
+	outfile := cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
+	cmd.Require(flag.Exact, 1)
+	cmd.ParseFlags(args, true)
+
+	var (
+		output = cli.out
+		err    error
+	)
+	if *outfile != "" {
+		output, err = os.Create(*outfile)
+		if err != nil {
+			return err
+		}
+	} else if cli.isTerminalOut {
+		return errors.New("Cowardly refusing to save to a terminal. Use the -o flag or redirect.")
+	}
+
+	sopts := &streamOpts{
+		rawTerminal: true,
+		out:         output,
+	}
+	if _, err := cli.stream("GET", "/volumes/"+cmd.Arg(0)+"/export", sopts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CmdVolumeImport creates a volume with the content of a tar archive.
+//
+// The tar archive is read from STDIN by default, or from a file.
+//
+// Usage: docker volume import [OPTIONS] [FILE|-]
+func (cli *DockerCli) CmdVolumeImport(args ...string) error {
+	cmd := Cli.Subcmd("volume import", []string{"[FILE|-]"}, "Create a volume from a tar archive", true)
+	flDriver := cmd.String([]string{"d", "-driver"}, "local", "Specify volume driver name")
+	flName := cmd.String([]string{"-name"}, "", "Specify volume name")
+
+	flDriverOpts := opts.NewMapOpts(nil, nil)
+	cmd.Var(flDriverOpts, []string{"o", "-opt"}, "Set driver specific options")
+
+	flLabels := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flLabels, []string{"-label"}, "Set metadata for a volume")
+
+	cmd.Require(flag.Max, 1)
+	cmd.ParseFlags(args, true)
+
+	v := url.Values{}
+	v.Set("name", *flName)
+	v.Set("driver", *flDriver)
+	if driverOpts := flDriverOpts.GetAll(); len(driverOpts) > 0 {
+		optsJSON, err := json.Marshal(driverOpts)
+		if err != nil {
+			return err
+		}
+		v.Set("opts", string(optsJSON))
+	}
+	if labels := runconfig.ConvertKVStringsToMap(flLabels.GetAll()); len(labels) > 0 {
+		labelsJSON, err := json.Marshal(labels)
+		if err != nil {
+			return err
+		}
+		v.Set("labels", string(labelsJSON))
+	}
+
+	var input io.Reader = cli.in
+	if src := cmd.Arg(0); src != "" && src != "-" {
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	resp, err := cli.clientRequest("POST", "/volumes/import?"+v.Encode(), input, map[string][]string{"Content-Type": {"application/x-tar"}})
+	if err != nil {
+		return err
+	}
+	defer resp.body.Close()
+
+	var vol types.Volume
+	if err := json.NewDecoder(resp.body).Decode(&vol); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", vol.Name)
+	return nil
+}
+
+// CmdVolumeResize grows a volume to a new size.
+//
+// Usage: docker volume resize VOLUME SIZE
+func (cli *DockerCli) CmdVolumeResize(args ...string) error {
+	cmd := Cli.Subcmd("volume resize", []string{"VOLUME SIZE"}, "Grow a volume", true)
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	name := cmd.Arg(0)
+	size, err := units.RAMInBytes(cmd.Arg(1))
+	if err != nil {
+		return fmt.Errorf("Invalid size %s: %v", cmd.Arg(1), err)
+	}
+
+	req := &types.VolumeResizeRequest{Size: size}
+	if _, err := cli.call("POST", "/volumes/"+name+"/resize", req, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", name)
+	return nil
+}
+
+// CmdVolumeSnapshot is the parent subcommand for the snapshot commands of
+// volumes.
+//
+// Usage: docker volume snapshot <COMMAND> <OPTS>
+func (cli *DockerCli) CmdVolumeSnapshot(args ...string) error {
+	commands := map[string]func(...string) error{
+		"create": cli.volumeSnapshotCreate,
+		"ls":     cli.volumeSnapshotLs,
+		"rm":     cli.volumeSnapshotRm,
+		"clone":  cli.volumeSnapshotClone,
+	}
+	if len(args) > 0 {
+		if run, ok := commands[args[0]]; ok {
+			return run(args[1:]...)
+		}
+	}
+
+	description := "Manage snapshots of a volume\n\nCommands:\n"
+	for _, cmd := range [][]string{
+		{"create", "Take a snapshot of a volume"},
+		{"ls", "List the snapshots of a volume"},
+		{"rm", "Remove a snapshot"},
+		{"clone", "Create a volume from a snapshot"},
+	} {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker volume snapshot COMMAND --help' for more information on a command"
+	cmd := Cli.Subcmd("volume snapshot", []string{"[COMMAND]"}, description, false)
+
+	cmd.Require(flag.Exact, 0)
+	err := cmd.ParseFlags(args, true)
+	cmd.Usage()
+	return err
+}
+
+// volumeSnapshotCreate takes a snapshot of a volume.
+//
+// Usage: docker volume snapshot create VOLUME SNAPSHOT
+func (cli *DockerCli) volumeSnapshotCreate(args ...string) error {
+	cmd := Cli.Subcmd("volume snapshot create", []string{"VOLUME SNAPSHOT"}, "Take a snapshot of a volume", true)
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	volume, name := cmd.Arg(0), cmd.Arg(1)
+	req := &types.VolumeSnapshotCreateRequest{Name: name}
+	resp, err := cli.call("POST", "/volumes/"+volume+"/snapshots", req, nil)
+	if err != nil {
+		return err
+	}
+
+	var snapshot types.VolumeSnapshot
+	if err := json.NewDecoder(resp.body).Decode(&snapshot); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", snapshot.Name)
+	return nil
+}
+
+// volumeSnapshotLs lists the snapshots of a volume.
+//
+// Usage: docker volume snapshot ls [OPTIONS] VOLUME
+func (cli *DockerCli) volumeSnapshotLs(args ...string) error {
+	cmd := Cli.Subcmd("volume snapshot ls", []string{"VOLUME"}, "List the snapshots of a volume", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display snapshot names")
+	cmd.Require(flag.Exact, 1)
+	cmd.ParseFlags(args, true)
+
+	resp, err := cli.call("GET", "/volumes/"+cmd.Arg(0)+"/snapshots", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var snapshots types.VolumeSnapshotsListResponse
+	if err := json.NewDecoder(resp.body).Decode(&snapshots); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintf(w, "SNAPSHOT NAME\tSIZE\n")
+	}
+	for _, snap := range snapshots.Snapshots {
+		if *quiet {
+			fmt.Fprintln(w, snap.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", snap.Name, units.HumanSize(float64(snap.Size)))
+	}
+	w.Flush()
+	return nil
+}
+
+// volumeSnapshotRm removes one or more snapshots of a volume.
+//
+// Usage: docker volume snapshot rm VOLUME SNAPSHOT [SNAPSHOT...]
+func (cli *DockerCli) volumeSnapshotRm(args ...string) error {
+	cmd := Cli.Subcmd("volume snapshot rm", []string{"VOLUME SNAPSHOT [SNAPSHOT...]"}, "Remove a snapshot", true)
+	cmd.Require(flag.Min, 2)
+	cmd.ParseFlags(args, true)
+
+	var status = 0
+	volume := cmd.Arg(0)
+	for _, name := range cmd.Args()[1:] {
+		_, err := cli.call("DELETE", "/volumes/"+volume+"/snapshots/"+name, nil, nil)
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+
+	if status != 0 {
+		return Cli.StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+// volumeSnapshotClone creates a new volume from a snapshot of a volume.
+//
+// Usage: docker volume snapshot clone [OPTIONS] VOLUME SNAPSHOT
+func (cli *DockerCli) volumeSnapshotClone(args ...string) error {
+	cmd := Cli.Subcmd("volume snapshot clone", []string{"VOLUME SNAPSHOT"}, "Create a volume from a snapshot", true)
+	flName := cmd.String([]string{"-name"}, "", "Specify volume name")
+
+	flDriverOpts := opts.NewMapOpts(nil, nil)
+	cmd.Var(flDriverOpts, []string{"o", "-opt"}, "Set driver specific options")
+
+	flLabels := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flLabels, []string{"-label"}, "Set metadata for a volume")
+
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	req := &types.VolumeSnapshotCloneRequest{
+		Name:       *flName,
+		DriverOpts: flDriverOpts.GetAll(),
+		Labels:     runconfig.ConvertKVStringsToMap(flLabels.GetAll()),
+	}
+	resp, err := cli.call("POST", "/volumes/"+cmd.Arg(0)+"/snapshots/"+cmd.Arg(1)+"/clone", req, nil)
+	if err != nil {
+		return err
+	}
+
+	var vol types.Volume
+	if err := json.NewDecoder(resp.body).Decode(&vol); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", vol.Name)
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/sara-nl/docker-1.9.1/api/server/httputils"
@@ -228,7 +229,14 @@ func (s *router) getImagesGet(ctx context.Context, w http.ResponseWriter, r *htt
 		names = r.Form["names"]
 	}
 
-	if err := s.daemon.ExportImage(names, output); err != nil {
+	imageExportConfig := &graph.ImageExportConfig{
+		Names:       names,
+		ExcludeBase: r.Form.Get("exclude-base"),
+		Format:      r.Form.Get("format"),
+		Sign:        httputils.BoolValue(r, "sign"),
+		Outstream:   output,
+	}
+	if err := s.daemon.ExportImage(imageExportConfig); err != nil {
 		if !output.Flushed() {
 			return err
 		}
@@ -242,6 +250,28 @@ func (s *router) postImagesLoad(ctx context.Context, w http.ResponseWriter, r *h
 	return s.daemon.LoadImage(r.Body, w)
 }
 
+func (s *router) postImagesPrune(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	var unusedFor time.Duration
+	if v := r.Form.Get("unused-for"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid unused-for duration %q: %v", v, err)
+		}
+		unusedFor = d
+	}
+
+	report, err := s.daemon.ImagesPrune(unusedFor, r.Form["keep"])
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, report)
+}
+
 func (s *router) deleteImages(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -267,6 +297,27 @@ func (s *router) deleteImages(ctx context.Context, w http.ResponseWriter, r *htt
 	return httputils.WriteJSON(w, http.StatusOK, list)
 }
 
+func (s *router) getImagesDiff(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	other := r.Form.Get("other")
+	if other == "" {
+		return fmt.Errorf("Missing parameter: other")
+	}
+
+	changes, err := s.daemon.ImageDiff(vars["name"], other, httputils.BoolValue(r, "size"))
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, changes)
+}
+
 func (s *router) getImagesByName(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -493,10 +544,54 @@ func (s *router) postImagesTag(ctx context.Context, w http.ResponseWriter, r *ht
 	return nil
 }
 
+func (s *router) postImagesSquash(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	img, err := s.daemon.SquashImage(vars["name"], r.Form.Get("from"), r.Form.Get("repo"), r.Form.Get("tag"))
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusCreated, &types.ImageSquashResponse{
+		ID: img.ID,
+	})
+}
+
 func (s *router) getImagesSearch(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
 	}
+	config, headers := registryAuthAndHeaders(r)
+	query, err := s.daemon.SearchRegistryForImages(r.Form.Get("term"), r.Form.Get("registry"), config, headers)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, query.Results)
+}
+
+func (s *router) getImagesTags(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	config, headers := registryAuthAndHeaders(r)
+	tags, err := s.daemon.ListRemoteTags(vars["name"], config, headers)
+	if err != nil {
+		return err
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	return httputils.WriteJSON(w, http.StatusOK, tags)
+}
+
+// registryAuthAndHeaders returns the registry credentials and the meta
+// headers of a request which queries a registry.
+func registryAuthAndHeaders(r *http.Request) (*cliconfig.AuthConfig, map[string][]string) {
 	var (
 		config      *cliconfig.AuthConfig
 		authEncoded = r.Header.Get("X-Registry-Auth")
@@ -516,9 +611,5 @@ func (s *router) getImagesSearch(ctx context.Context, w http.ResponseWriter, r *
 			headers[k] = v
 		}
 	}
-	query, err := s.daemon.SearchRegistryForImages(r.Form.Get("term"), config, headers)
-	if err != nil {
-		return err
-	}
-	return httputils.WriteJSON(w, http.StatusOK, query.Results)
+	return config, headers
 }
@@ -104,8 +104,10 @@ func (r *router) initRoutes() {
 		NewGetRoute("/images/search", r.getImagesSearch),
 		NewGetRoute("/images/get", r.getImagesGet),
 		NewGetRoute("/images/{name:.*}/get", r.getImagesGet),
+		NewGetRoute("/images/{name:.*}/diff", r.getImagesDiff),
 		NewGetRoute("/images/{name:.*}/history", r.getImagesHistory),
 		NewGetRoute("/images/{name:.*}/json", r.getImagesByName),
+		NewGetRoute("/images/{name:.*}/tags", r.getImagesTags),
 		NewGetRoute("/containers/json", r.getContainersJSON),
 		NewGetRoute("/containers/{name:.*}/export", r.getContainersExport),
 		NewGetRoute("/containers/{name:.*}/changes", r.getContainersChanges),
@@ -117,6 +119,8 @@ func (r *router) initRoutes() {
 		NewGetRoute("/exec/{id:.*}/json", r.getExecByID),
 		NewGetRoute("/containers/{name:.*}/archive", r.getContainersArchive),
 		NewGetRoute("/volumes", r.getVolumesList),
+		NewGetRoute("/volumes/{name:.*}/snapshots", r.getVolumeSnapshots),
+		NewGetRoute("/volumes/{name:.*}/export", r.getVolumeExport),
 		NewGetRoute("/volumes/{name:.*}", r.getVolumeByName),
 		// POST
 		NewPostRoute("/auth", r.postAuth),
@@ -124,8 +128,10 @@ func (r *router) initRoutes() {
 		NewPostRoute("/build", r.postBuild),
 		NewPostRoute("/images/create", r.postImagesCreate),
 		NewPostRoute("/images/load", r.postImagesLoad),
+		NewPostRoute("/images/prune", r.postImagesPrune),
 		NewPostRoute("/images/{name:.*}/push", r.postImagesPush),
 		NewPostRoute("/images/{name:.*}/tag", r.postImagesTag),
+		NewPostRoute("/images/{name:.*}/squash", r.postImagesSquash),
 		NewPostRoute("/containers/create", r.postContainersCreate),
 		NewPostRoute("/containers/{name:.*}/kill", r.postContainersKill),
 		NewPostRoute("/containers/{name:.*}/pause", r.postContainersPause),
@@ -142,11 +148,16 @@ func (r *router) initRoutes() {
 		NewPostRoute("/exec/{name:.*}/resize", r.postContainerExecResize),
 		NewPostRoute("/containers/{name:.*}/rename", r.postContainerRename),
 		NewPostRoute("/volumes/create", r.postVolumesCreate),
+		NewPostRoute("/volumes/import", r.postVolumesImport),
+		NewPostRoute("/volumes/{name:.*}/resize", r.postVolumeResize),
+		NewPostRoute("/volumes/{name:.*}/snapshots", r.postVolumeSnapshotsCreate),
+		NewPostRoute("/volumes/{name:.*}/snapshots/{snapshot}/clone", r.postVolumeSnapshotClone),
 		// PUT
 		NewPutRoute("/containers/{name:.*}/archive", r.putContainersArchive),
 		// DELETE
 		NewDeleteRoute("/containers/{name:.*}", r.deleteContainers),
 		NewDeleteRoute("/images/{name:.*}", r.deleteImages),
+		NewDeleteRoute("/volumes/{name:.*}/snapshots/{snapshot}", r.deleteVolumeSnapshot),
 		NewDeleteRoute("/volumes/{name:.*}", r.deleteVolumes),
 	}
 }
@@ -2,8 +2,10 @@ package local
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/sara-nl/docker-1.9.1/api/server/httputils"
 	"github.com/sara-nl/docker-1.9.1/api/types"
 	"golang.org/x/net/context"
@@ -14,7 +16,7 @@ func (s *router) getVolumesList(ctx context.Context, w http.ResponseWriter, r *h
 		return err
 	}
 
-	volumes, err := s.daemon.Volumes(r.Form.Get("filters"))
+	volumes, err := s.daemon.Volumes(r.Form.Get("filters"), httputils.BoolValue(r, "size"))
 	if err != nil {
 		return err
 	}
@@ -47,7 +49,7 @@ func (s *router) postVolumesCreate(ctx context.Context, w http.ResponseWriter, r
 		return err
 	}
 
-	volume, err := s.daemon.VolumeCreate(req.Name, req.Driver, req.DriverOpts)
+	volume, err := s.daemon.VolumeCreate(req.Name, req.Driver, req.DriverOpts, req.Labels)
 	if err != nil {
 		return err
 	}
@@ -64,3 +66,129 @@ func (s *router) deleteVolumes(ctx context.Context, w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
+
+func (s *router) getVolumeSnapshots(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	snapshots, err := s.daemon.VolumeSnapshots(vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, &types.VolumeSnapshotsListResponse{Snapshots: snapshots})
+}
+
+func (s *router) postVolumeSnapshotsCreate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	var req types.VolumeSnapshotCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	snapshot, err := s.daemon.VolumeSnapshotCreate(vars["name"], req.Name)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, snapshot)
+}
+
+func (s *router) postVolumeSnapshotClone(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	var req types.VolumeSnapshotCloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	volume, err := s.daemon.VolumeSnapshotClone(vars["name"], vars["snapshot"], req.Name, req.DriverOpts, req.Labels)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, volume)
+}
+
+func (s *router) deleteVolumeSnapshot(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	if err := s.daemon.VolumeSnapshotRm(vars["name"], vars["snapshot"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *router) postVolumeResize(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	var req types.VolumeResizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	if err := s.daemon.VolumeResize(vars["name"], req.Size); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *router) getVolumeExport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	// the volume is mounted before the headers are written, so that
+	// failing to do so is reported with an error status
+	data, err := s.daemon.VolumeExport(vars["name"])
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	if _, err := io.Copy(w, data); err != nil {
+		logrus.Errorf("Error exporting volume %s: %v", vars["name"], err)
+	}
+	return nil
+}
+
+func (s *router) postVolumesImport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	var opts, labels map[string]string
+	if optsJSON := r.FormValue("opts"); optsJSON != "" {
+		if err := json.Unmarshal([]byte(optsJSON), &opts); err != nil {
+			return err
+		}
+	}
+	if labelsJSON := r.FormValue("labels"); labelsJSON != "" {
+		if err := json.Unmarshal([]byte(labelsJSON), &labels); err != nil {
+			return err
+		}
+	}
+
+	volume, err := s.daemon.VolumeImport(r.Form.Get("name"), r.Form.Get("driver"), opts, labels, r.Body)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, volume)
+}
@@ -48,6 +48,22 @@ type ContainerCommitResponse struct {
 	ID string `json:"Id"`
 }
 
+// ImageSquashResponse contains response of Remote API:
+// POST "/images/{name:.*}/squash"
+type ImageSquashResponse struct {
+	ID string `json:"Id"`
+}
+
+// ImageChange contains response of Remote API:
+// GET "/images/{name:.*}/diff"
+type ImageChange struct {
+	Path string
+	Kind int
+	// SizeDelta is the difference in size of the file, in bytes. It is
+	// only set if the size deltas were requested.
+	SizeDelta int64 `json:",omitempty"`
+}
+
 // ContainerChange contains response of Remote API:
 // GET "/containers/{name:.*}/changes"
 type ContainerChange struct {
@@ -73,6 +89,13 @@ type ImageDelete struct {
 	Deleted  string `json:",omitempty"`
 }
 
+// ImagesPruneReport contains response of Remote API:
+// POST "/images/prune"
+type ImagesPruneReport struct {
+	ImagesDeleted  []ImageDelete
+	SpaceReclaimed uint64
+}
+
 // Image contains response of Remote API:
 // GET "/images/json"
 type Image struct {
@@ -84,6 +107,9 @@ type Image struct {
 	Size        int64
 	VirtualSize int64
 	Labels      map[string]string
+	LastUsed    int64
+	LastPulled  int64
+	UseCount    int
 }
 
 // GraphDriverData returns Image's graph driver config info
@@ -329,9 +355,20 @@ type MountPoint struct {
 
 // Volume represents the configuration of a volume for the remote API
 type Volume struct {
-	Name       string // Name is the name of the volume
-	Driver     string // Driver is the Driver name used to create the volume
-	Mountpoint string // Mountpoint is the location on disk of the volume
+	Name       string            // Name is the name of the volume
+	Driver     string            // Driver is the Driver name used to create the volume
+	Mountpoint string            // Mountpoint is the location on disk of the volume
+	Labels     map[string]string // Labels is the metadata specified when the volume was created
+	Scope      string            `json:",omitempty"` // Scope is the scope of the volume driver, "local" or "global"
+	Usage      *VolumeUsage      `json:",omitempty"` // Usage is the space used by the volume and the containers referencing it
+}
+
+// VolumeUsage represents the usage of a volume for the remote API
+type VolumeUsage struct {
+	Size       int64    // Size is the number of bytes used by the volume, or -1 if it is unknown
+	Capacity   int64    `json:",omitempty"` // Capacity is the size of the volume in bytes, if it has a fixed size
+	RefCount   int      // RefCount is the number of references to the volume by containers
+	Containers []string // Containers holds the IDs of the containers referencing the volume
 }
 
 // VolumesListResponse contains the response for the remote API:
@@ -346,6 +383,39 @@ type VolumeCreateRequest struct {
 	Name       string            // Name is the requested name of the volume
 	Driver     string            // Driver is the name of the driver that should be used to create the volume
 	DriverOpts map[string]string // DriverOpts holds the driver specific options to use for when creating the volume.
+	Labels     map[string]string // Labels holds metadata specific to the volume being created.
+}
+
+// VolumeResizeRequest contains the request for the remote API:
+// POST "/volumes/{name}/resize"
+type VolumeResizeRequest struct {
+	Size int64 // Size is the new size of the volume, in bytes
+}
+
+// VolumeSnapshot represents a snapshot of a volume for the remote API
+type VolumeSnapshot struct {
+	Name string // Name is the name of the snapshot
+	Size int64  // Size is the size of the volume when the snapshot was taken, in bytes
+}
+
+// VolumeSnapshotsListResponse contains the response for the remote API:
+// GET "/volumes/{name}/snapshots"
+type VolumeSnapshotsListResponse struct {
+	Snapshots []*VolumeSnapshot // Snapshots is the list of snapshots being returned
+}
+
+// VolumeSnapshotCreateRequest contains the request for the remote API:
+// POST "/volumes/{name}/snapshots"
+type VolumeSnapshotCreateRequest struct {
+	Name string // Name is the requested name of the snapshot
+}
+
+// VolumeSnapshotCloneRequest contains the request for the remote API:
+// POST "/volumes/{name}/snapshots/{snapshot}/clone"
+type VolumeSnapshotCloneRequest struct {
+	Name       string            // Name is the requested name of the new volume
+	DriverOpts map[string]string // DriverOpts holds additional driver specific options for the new volume
+	Labels     map[string]string // Labels holds metadata specific to the new volume
 }
 
 // NetworkResource is the body of the "get network" http response message
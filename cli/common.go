@@ -36,6 +36,7 @@ var dockerCommands = []Command{
 	{"exec", "Run a command in a running container"},
 	{"export", "Export a container's filesystem as a tar archive"},
 	{"history", "Show the history of an image"},
+	{"image", "Manage Docker images"},
 	{"images", "List images"},
 	{"import", "Import the contents from a tarball to create a filesystem image"},
 	{"info", "Display system-wide information"},
@@ -62,6 +63,7 @@ var dockerCommands = []Command{
 	{"stats", "Display a live stream of container(s) resource usage statistics"},
 	{"stop", "Stop a running container"},
 	{"tag", "Tag an image into a repository"},
+	{"tags", "List the tags of a repository in its registry"},
 	{"top", "Display the running processes of a container"},
 	{"unpause", "Unpause all processes within a container"},
 	{"version", "Show the Docker version information"},
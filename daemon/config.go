@@ -1,14 +1,17 @@
 package daemon
 
 import (
+	"time"
+
 	"github.com/sara-nl/docker-1.9.1/opts"
 	flag "github.com/sara-nl/docker-1.9.1/pkg/mflag"
 	"github.com/sara-nl/docker-1.9.1/runconfig"
 )
 
 const (
-	defaultNetworkMtu    = 1500
-	disableNetworkBridge = "none"
+	defaultNetworkMtu             = 1500
+	disableNetworkBridge          = "none"
+	defaultMaxConcurrentDownloads = 3
 )
 
 // CommonConfig defines the configuration of a docker daemon which are
@@ -48,6 +51,38 @@ type CommonConfig struct {
 	// discovery. This should be a 'host:port' combination on which that daemon instance is
 	// reachable by other hosts.
 	ClusterAdvertise string
+
+	// ImageGCInterval is how often the image garbage collector runs. A zero
+	// interval disables the periodic collection; images can still be pruned
+	// through the API.
+	ImageGCInterval time.Duration
+
+	// ImageGCMaxAge removes images that have not been used by a container for
+	// longer than this duration. Zero disables removal by age.
+	ImageGCMaxAge time.Duration
+
+	// ImageGCHighThreshold and ImageGCLowThreshold are percentages of disk
+	// usage of the daemon root. Once usage reaches the high threshold, the
+	// least recently used images are removed until it drops below the low
+	// threshold. A zero high threshold disables removal on disk pressure.
+	ImageGCHighThreshold int
+	ImageGCLowThreshold  int
+
+	// ImageGCKeep holds the patterns of image references that the garbage
+	// collector never removes.
+	ImageGCKeep []string
+
+	// MaxConcurrentDownloads is the maximum number of layers the daemon
+	// downloads at the same time, across all pulls.
+	MaxConcurrentDownloads int
+
+	// BundleTrustKeys holds the paths of the files with the public keys
+	// trusted to sign image bundles, besides the key of the daemon.
+	BundleTrustKeys []string
+
+	// RequireTrustedBundles rejects image bundles whose manifests are not
+	// signed by a trusted key.
+	RequireTrustedBundles bool
 }
 
 // InstallCommonFlags adds command-line options to the top-level flag parser for
@@ -74,4 +109,12 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.StringVar(&config.ClusterAdvertise, []string{"-cluster-advertise"}, "", usageFn("Address or interface name to advertise"))
 	cmd.StringVar(&config.ClusterStore, []string{"-cluster-store"}, "", usageFn("Set the cluster store"))
 	cmd.Var(opts.NewMapOpts(config.ClusterOpts, nil), []string{"-cluster-store-opt"}, usageFn("Set cluster store options"))
+	cmd.DurationVar(&config.ImageGCInterval, []string{"-image-gc-interval"}, 0, usageFn("Interval between image garbage collections, 0 disables"))
+	cmd.DurationVar(&config.ImageGCMaxAge, []string{"-image-gc-max-age"}, 0, usageFn("Remove images unused for longer than this duration"))
+	cmd.IntVar(&config.ImageGCHighThreshold, []string{"-image-gc-high-threshold"}, 90, usageFn("Disk usage percentage that triggers image garbage collection"))
+	cmd.IntVar(&config.ImageGCLowThreshold, []string{"-image-gc-low-threshold"}, 80, usageFn("Disk usage percentage image garbage collection frees down to"))
+	cmd.Var(opts.NewListOptsRef(&config.ImageGCKeep, nil), []string{"-image-gc-keep"}, usageFn("Image reference pattern never garbage collected"))
+	cmd.IntVar(&config.MaxConcurrentDownloads, []string{"-max-concurrent-downloads"}, defaultMaxConcurrentDownloads, usageFn("Maximum number of layers downloaded at the same time, 0 for no limit"))
+	cmd.Var(opts.NewListOptsRef(&config.BundleTrustKeys, nil), []string{"-bundle-trust-key"}, usageFn("Public key file trusted to sign image bundles"))
+	cmd.BoolVar(&config.RequireTrustedBundles, []string{"-require-trusted-bundles"}, false, usageFn("Only load image bundles signed by a trusted key"))
 }
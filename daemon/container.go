@@ -29,6 +29,7 @@ import (
 	"github.com/sara-nl/docker-1.9.1/pkg/nat"
 	"github.com/sara-nl/docker-1.9.1/pkg/promise"
 	"github.com/sara-nl/docker-1.9.1/pkg/signal"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
 	"github.com/sara-nl/docker-1.9.1/pkg/symlink"
 	"github.com/sara-nl/docker-1.9.1/runconfig"
 	"github.com/sara-nl/docker-1.9.1/volume"
@@ -315,7 +316,7 @@ func (container *Container) Start() (err error) {
 		}
 	}
 
-	mounts, err := container.setupMounts()
+	mounts, err := container.setupMounts(false)
 	if err != nil {
 		return err
 	}
@@ -1089,13 +1090,20 @@ func (container *Container) shouldRestart() bool {
 		(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0)
 }
 
+// mountVolumes mounts the volumes of the container in its root filesystem to
+// copy files from or to it. The volumes are mounted transiently, and must be
+// unmounted with unmountVolumes(true).
 func (container *Container) mountVolumes() error {
-	mounts, err := container.setupMounts()
+	mounts, err := container.setupMounts(true)
 	if err != nil {
 		return err
 	}
 
 	for _, m := range mounts {
+		// a tmpfs only exists in the mount namespace of the container
+		if m.FsType == "tmpfs" {
+			continue
+		}
 		dest, err := container.GetResourcePath(m.Destination)
 		if err != nil {
 			return err
@@ -1136,16 +1144,18 @@ func (container *Container) copyImagePathContent(v volume.Volume, destination st
 		return err
 	}
 
-	path, err := v.Mount()
+	id := stringid.GenerateNonCryptoID()
+	path, err := acquireVolume(v, id, container.ID, true)
 	if err != nil {
 		return err
 	}
 
 	if err := copyExistingContents(rootfs, path); err != nil {
+		releaseVolume(v, id, container.ID)
 		return err
 	}
 
-	return v.Unmount()
+	return releaseVolume(v, id, container.ID)
 }
 
 func (container *Container) stopSignal() int {
@@ -1266,6 +1266,9 @@ func (container *Container) disconnectFromNetwork(n libnetwork.Network) error {
 	return nil
 }
 
+// unmountVolumes unmounts the volumes of the container. forceSyscall is set
+// by the callers of mountVolumes, whose transient mounts are released
+// instead of the ones of the container.
 func (container *Container) unmountVolumes(forceSyscall bool) error {
 	var volumeMounts []mountPoint
 
@@ -1275,7 +1278,12 @@ func (container *Container) unmountVolumes(forceSyscall bool) error {
 			return err
 		}
 
-		volumeMounts = append(volumeMounts, mountPoint{Destination: dest, Volume: mntPoint.Volume})
+		id := mntPoint.ID
+		if forceSyscall {
+			id = mntPoint.copyID
+			mntPoint.copyID = ""
+		}
+		volumeMounts = append(volumeMounts, mountPoint{Destination: dest, Volume: mntPoint.Volume, ID: id})
 	}
 
 	for _, mnt := range container.networkMounts() {
@@ -1290,15 +1298,25 @@ func (container *Container) unmountVolumes(forceSyscall bool) error {
 	for _, volumeMount := range volumeMounts {
 		if forceSyscall {
 			syscall.Unmount(volumeMount.Destination, 0)
+			if volumeMount.ID == "" {
+				// not mounted, or not a volume
+				continue
+			}
 		}
 
 		if volumeMount.Volume != nil {
-			if err := volumeMount.Volume.Unmount(); err != nil {
+			if err := releaseVolume(volumeMount.Volume, volumeMount.ID, container.ID); err != nil {
 				return err
 			}
 		}
 	}
 
+	// the next start mounts the volumes with new IDs
+	if !forceSyscall {
+		for _, m := range container.MountPoints {
+			m.ID = ""
+		}
+	}
 	return nil
 }
 
@@ -1388,6 +1406,9 @@ func (container *Container) addMountPointWithVolume(destination string, vol volu
 }
 
 func (container *Container) isDestinationMounted(destination string) bool {
+	if _, exists := container.hostConfig.Tmpfs[destination]; exists {
+		return true
+	}
 	return container.MountPoints[destination] != nil
 }
 
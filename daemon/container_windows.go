@@ -106,12 +106,13 @@ func populateCommand(c *Container, env []string) error {
 		return derr.ErrorCodeGetGraph.WithArgs(c.ImageID, err)
 	}
 	for i := img; i != nil && err == nil; i, err = c.daemon.graph.GetParent(i) {
-		lp, err := c.daemon.driver.Get(i.ID, "")
+		layer := c.daemon.graph.LayerID(i.ID)
+		lp, err := c.daemon.driver.Get(layer, "")
 		if err != nil {
 			return derr.ErrorCodeGetLayer.WithArgs(c.daemon.driver.String(), i.ID, err)
 		}
 		layerPaths = append(layerPaths, lp)
-		err = c.daemon.driver.Put(i.ID)
+		err = c.daemon.driver.Put(layer)
 		if err != nil {
 			return derr.ErrorCodePutLayer.WithArgs(c.daemon.driver.String(), i.ID, err)
 		}
@@ -138,14 +138,14 @@ func (daemon *Daemon) generateSecurityOpt(ipcMode runconfig.IpcMode, pidMode run
 	return nil, nil
 }
 
-// VolumeCreate creates a volume with the specified name, driver, and opts
+// VolumeCreate creates a volume with the specified name, driver, opts and labels
 // This is called directly from the remote API
-func (daemon *Daemon) VolumeCreate(name, driverName string, opts map[string]string) (*types.Volume, error) {
+func (daemon *Daemon) VolumeCreate(name, driverName string, opts, labels map[string]string) (*types.Volume, error) {
 	if name == "" {
 		name = stringid.GenerateNonCryptoID()
 	}
 
-	v, err := daemon.volumes.Create(name, driverName, opts)
+	v, err := daemon.volumes.Create(name, driverName, opts, labels)
 	if err != nil {
 		return nil, err
 	}
@@ -154,5 +154,19 @@ func (daemon *Daemon) VolumeCreate(name, driverName string, opts map[string]stri
 	if (driverName != "" && v.DriverName() != driverName) || (driverName == "" && v.DriverName() != volume.DefaultDriverName) {
 		return nil, derr.ErrorVolumeNameTaken.WithArgs(name, v.DriverName())
 	}
-	return volumeToAPIType(v), nil
+	return daemon.volumeToAPIType(v), nil
+}
+
+// VolumeResize grows the volume with the given name to size bytes.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeResize(name string, size int64) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	r, ok := v.(volume.Resizer)
+	if !ok {
+		return derr.ErrorCodeVolumeNoResize.WithArgs(name, v.DriverName())
+	}
+	return r.Resize(size)
 }
@@ -57,6 +57,7 @@ import (
 	"github.com/sara-nl/docker-1.9.1/volume/nfs"
 	"github.com/sara-nl/docker-1.9.1/volume/store"
 	"github.com/docker/libnetwork"
+	"github.com/docker/libtrust"
 )
 
 var (
@@ -126,6 +127,8 @@ type Daemon struct {
 	shutdown         bool
 	uidMaps          []idtools.IDMap
 	gidMaps          []idtools.IDMap
+	imageGCLock      sync.Mutex    // serializes image garbage collections
+	imageGCShutdown  chan struct{} // closed on shutdown to stop the image GC
 }
 
 // Get looks for a container using the provided information, which could be
@@ -296,6 +299,14 @@ func (daemon *Daemon) restore() error {
 		if (container.Driver == "" && currentDriver == "aufs") || container.Driver == currentDriver {
 			logrus.Debugf("Loaded container %v", container.ID)
 
+			// The image may have been migrated to a content-addressable ID.
+			if img, err := daemon.graph.Get(container.ImageID); err == nil && img.ID != container.ImageID {
+				container.ImageID = img.ID
+				if err := container.toDisk(); err != nil {
+					logrus.Errorf("Failed to update the image of container %v: %v", container.ID, err)
+				}
+			}
+
 			containers[container.ID] = &cr{container: container}
 		} else {
 			logrus.Debugf("Cannot load container %s because it was created with another graph driver.", container.ID)
@@ -611,6 +622,12 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	if err := checkConfigOptions(config); err != nil {
 		return nil, err
 	}
+	if err := verifyImageGCSettings(config); err != nil {
+		return nil, err
+	}
+	if config.MaxConcurrentDownloads < 0 {
+		return nil, fmt.Errorf("max concurrent downloads can not be negative, got %d", config.MaxConcurrentDownloads)
+	}
 
 	// Do we have a disabled network?
 	config.DisableBridge = isBridgeNetworkDisabled(config)
@@ -727,6 +744,15 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 
+	var bundleTrustKeys []libtrust.PublicKey
+	for _, path := range config.BundleTrustKeys {
+		keys, err := libtrust.LoadKeySetFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading bundle trust keys from %s: %v", path, err)
+		}
+		bundleTrustKeys = append(bundleTrustKeys, keys...)
+	}
+
 	trustDir := filepath.Join(config.Root, "trust")
 
 	if err := system.MkdirAll(trustDir, 0700); err != nil {
@@ -736,10 +762,13 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	eventsService := events.New()
 	logrus.Debug("Creating repository list")
 	tagCfg := &graph.TagStoreConfig{
-		Graph:    g,
-		Key:      trustKey,
-		Registry: registryService,
-		Events:   eventsService,
+		Graph:                  g,
+		Key:                    trustKey,
+		Registry:               registryService,
+		Events:                 eventsService,
+		MaxConcurrentDownloads: config.MaxConcurrentDownloads,
+		TrustedKeys:            bundleTrustKeys,
+		RequireTrustedBundles:  config.RequireTrustedBundles,
 	}
 	repositories, err := graph.NewTagStore(filepath.Join(config.Root, "repositories-"+d.driver.String()), tagCfg)
 	if err != nil {
@@ -827,6 +856,10 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	}
 
 	go d.execCommandGC()
+	if config.ImageGCInterval > 0 {
+		d.imageGCShutdown = make(chan struct{})
+		go d.imageGC(config.ImageGCInterval, d.imageGCShutdown)
+	}
 
 	if err := d.restore(); err != nil {
 		return nil, err
@@ -838,6 +871,9 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 // Shutdown stops the daemon.
 func (daemon *Daemon) Shutdown() error {
 	daemon.shutdown = true
+	if daemon.imageGCShutdown != nil {
+		close(daemon.imageGCShutdown)
+	}
 	if daemon.containers != nil {
 		group := sync.WaitGroup{}
 		logrus.Debug("starting clean shutdown of all containers...")
@@ -991,7 +1027,7 @@ func (daemon *Daemon) createRootfs(container *Container) error {
 		return err
 	}
 	initID := fmt.Sprintf("%s-init", container.ID)
-	if err := daemon.driver.Create(initID, container.ImageID); err != nil {
+	if err := daemon.driver.Create(initID, daemon.graph.LayerID(container.ImageID)); err != nil {
 		return err
 	}
 	initPath, err := daemon.driver.Get(initID, "")
@@ -1031,6 +1067,19 @@ func (daemon *Daemon) TagImage(repoName, tag, imageName string, force bool) erro
 	return daemon.repositories.Tag(repoName, tag, imageName, force)
 }
 
+// SquashImage merges the layers of the image name on top of the image from
+// into a single layer, and tags the result as repo:tag if repo is given.
+func (daemon *Daemon) SquashImage(name, from, repo, tag string) (*image.Image, error) {
+	return daemon.repositories.Squash(name, from, repo, tag)
+}
+
+// ImageDiff returns the changes to the filesystem of the image name that
+// result in the filesystem of the image other. If size is true, the change
+// in size of each file is included.
+func (daemon *Daemon) ImageDiff(name, other string, size bool) ([]types.ImageChange, error) {
+	return daemon.repositories.ImageDiff(name, other, size)
+}
+
 // PullImage initiates a pull operation. image is the repository name to pull, and
 // tag may be either empty, or indicate a specific tag to pull.
 func (daemon *Daemon) PullImage(image string, tag string, imagePullConfig *graph.ImagePullConfig) error {
@@ -1045,13 +1094,13 @@ func (daemon *Daemon) ImportImage(src, repo, tag, msg string, inConfig io.ReadCl
 	return daemon.repositories.Import(src, repo, tag, msg, inConfig, outStream, containerConfig)
 }
 
-// ExportImage exports a list of images to the given output stream. The
-// exported images are archived into a tar when written to the output
+// ExportImage exports a list of images to the output stream of the config.
+// The exported images are archived into a tar when written to the output
 // stream. All images with the given tag and all versions containing
-// the same tag are exported. names is the set of tags to export, and
-// outStream is the writer which the images are written to.
-func (daemon *Daemon) ExportImage(names []string, outStream io.Writer) error {
-	return daemon.repositories.ImageExport(names, outStream)
+// the same tag are exported, except for the layers of the base image given
+// in the config.
+func (daemon *Daemon) ExportImage(imageExportConfig *graph.ImageExportConfig) error {
+	return daemon.repositories.ImageExport(imageExportConfig)
 }
 
 // PushImage initiates a push operation on the repository named localName.
@@ -1078,7 +1127,31 @@ func (daemon *Daemon) LoadImage(inTar io.ReadCloser, outStream io.Writer) error
 // named all controls whether all images in the graph are filtered, or just
 // the heads.
 func (daemon *Daemon) ListImages(filterArgs, filter string, all bool) ([]*types.Image, error) {
-	return daemon.repositories.Images(filterArgs, filter, all)
+	images, err := daemon.repositories.Images(filterArgs, filter, all)
+	if err != nil {
+		return nil, err
+	}
+
+	imageFilters, err := filters.FromParam(filterArgs)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := imageFilters["unused-since"]; !ok {
+		return images, nil
+	}
+	// The images of existing containers are in use, however long ago the
+	// containers were started.
+	used := make(map[string]bool)
+	for _, container := range daemon.List() {
+		used[container.ImageID] = true
+	}
+	unused := []*types.Image{}
+	for _, img := range images {
+		if !used[img.ID] {
+			unused = append(unused, img)
+		}
+	}
+	return unused, nil
 }
 
 // ImageHistory returns a slice of ImageHistory structures for the specified image
@@ -1271,12 +1344,35 @@ func configureVolumes(config *Config, rootUID, rootGID int) (*store.VolumeStore,
 	}
 
 	volumedrivers.Register(volumesDriver, volumesDriver.Name())
-	s := store.New()
-	s.AddAll(volumesDriver.List())
-	cephVolumesDriver := cephvolumedriver.New()
+	s, err := store.New(config.Root)
+	if err != nil {
+		return nil, err
+	}
+	vols, err := volumesDriver.List()
+	if err != nil {
+		return nil, err
+	}
+	s.AddAll(vols)
+
+	cephVolumesDriver, err := cephvolumedriver.New(config.Root)
+	if err != nil {
+		return nil, err
+	}
 	volumedrivers.Register(cephVolumesDriver, cephVolumesDriver.Name())
-	nfsVolumesDriver := nfsvolumedriver.New()
+	if vols, err = cephVolumesDriver.List(); err != nil {
+		return nil, err
+	}
+	s.AddAll(vols)
+
+	nfsVolumesDriver, err := nfsvolumedriver.New(config.Root)
+	if err != nil {
+		return nil, err
+	}
 	volumedrivers.Register(nfsVolumesDriver, nfsVolumesDriver.Name())
+	if vols, err = nfsVolumesDriver.List(); err != nil {
+		return nil, err
+	}
+	s.AddAll(vols)
 	return s, nil
 }
 
@@ -1286,9 +1382,18 @@ func (daemon *Daemon) AuthenticateToRegistry(authConfig *cliconfig.AuthConfig) (
 }
 
 // SearchRegistryForImages queries the registry for images matching
-// term. authConfig is used to login.
-func (daemon *Daemon) SearchRegistryForImages(term string,
+// term. If registryName is empty, the registry is the one of term.
+// authConfig is used to login.
+func (daemon *Daemon) SearchRegistryForImages(term, registryName string,
 	authConfig *cliconfig.AuthConfig,
 	headers map[string][]string) (*registry.SearchResults, error) {
-	return daemon.RegistryService.Search(term, authConfig, headers)
+	return daemon.RegistryService.Search(term, registryName, authConfig, headers)
+}
+
+// ListRemoteTags lists the tags of the repository name in its registry.
+// authConfig is used to login.
+func (daemon *Daemon) ListRemoteTags(name string,
+	authConfig *cliconfig.AuthConfig,
+	headers map[string][]string) ([]string, error) {
+	return daemon.RegistryService.Tags(name, authConfig, headers)
 }
@@ -487,7 +487,7 @@ func TestRemoveLocalVolumesFollowingSymlinks(t *testing.T) {
 	}
 
 	m := c.MountPoints["/vol1"]
-	_, err = daemon.VolumeCreate(m.Name, m.Driver, nil)
+	_, err = daemon.VolumeCreate(m.Name, m.Driver, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -503,10 +503,14 @@ func TestRemoveLocalVolumesFollowingSymlinks(t *testing.T) {
 }
 
 func initDaemonWithVolumeStore(tmp string) (*Daemon, error) {
+	volumes, err := store.New(tmp)
+	if err != nil {
+		return nil, err
+	}
 	daemon := &Daemon{
 		repository: tmp,
 		root:       tmp,
-		volumes:    store.New(),
+		volumes:    volumes,
 	}
 
 	volumesDriver, err := local.New(tmp, 0, 0)
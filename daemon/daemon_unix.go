@@ -17,6 +17,7 @@ import (
 	derr "github.com/sara-nl/docker-1.9.1/errors"
 	"github.com/sara-nl/docker-1.9.1/pkg/fileutils"
 	"github.com/sara-nl/docker-1.9.1/pkg/idtools"
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
 	"github.com/sara-nl/docker-1.9.1/pkg/parsers"
 	"github.com/sara-nl/docker-1.9.1/pkg/parsers/kernel"
 	"github.com/sara-nl/docker-1.9.1/pkg/sysinfo"
@@ -127,6 +128,15 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *runconfig.HostC
 		return warnings, err
 	}
 
+	for dest, data := range hostConfig.Tmpfs {
+		if !filepath.IsAbs(dest) || filepath.Clean(dest) == "/" {
+			return warnings, fmt.Errorf("Invalid tmpfs mount: %q must be an absolute path other than '/'", dest)
+		}
+		if _, _, err := mount.ParseTmpfsOptions(data); err != nil {
+			return warnings, err
+		}
+	}
+
 	if hostConfig.LxcConf.Len() > 0 && !strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
 		return warnings, fmt.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
 	}
@@ -172,7 +172,11 @@ type Mount struct {
 	Writable    bool   `json:"writable"`
 	Private     bool   `json:"private"`
 	Slave       bool   `json:"slave"`
-	Driver      string `json:"driver"`
+	// FsType is set when Source is a block device or network filesystem
+	// that is mounted, rather than bind mounted, into the container.
+	FsType string `json:"fstype"`
+	// Data holds the mount options used along with FsType.
+	Data string `json:"data"`
 }
 
 // User contains the uid and gid representing a Unix user
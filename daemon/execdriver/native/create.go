@@ -8,6 +8,7 @@ import (
 	"syscall"
 
 	"github.com/sara-nl/docker-1.9.1/daemon/execdriver"
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
 
 	"github.com/opencontainers/runc/libcontainer/apparmor"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -296,6 +297,15 @@ func (d *Driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 	container.Mounts = defaultMounts
 
 	for _, m := range c.Mounts {
+		if m.FsType == "tmpfs" {
+			tmpfs, err := tmpfsMount(m)
+			if err != nil {
+				return err
+			}
+			container.Mounts = append(container.Mounts, tmpfs)
+			continue
+		}
+
 		flags := 0
 		if !m.Writable {
 			flags |= syscall.MS_RDONLY
@@ -304,8 +314,8 @@ func (d *Driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 			flags |= syscall.MS_SLAVE
 		}
 		device := "bind"
-		if m.Driver == "ceph" || m.Driver == "nfs" {
-			device = m.Driver
+		if m.FsType != "" {
+			device = m.FsType
 		} else {
 			flags |= syscall.MS_BIND | syscall.MS_REC
 		}
@@ -315,11 +325,36 @@ func (d *Driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 			Destination: m.Destination,
 			Device:      device,
 			Flags:       flags,
+			Data:        m.Data,
 		})
 	}
 	return nil
 }
 
+// tmpfsMount returns the configuration of a tmpfs mount. The defaults,
+// nosuid, nodev, noexec and a size of 64MB, can be overridden by the
+// options of the mount.
+func tmpfsMount(m execdriver.Mount) (*configs.Mount, error) {
+	options := "nosuid,nodev,noexec"
+	if m.Data != "" {
+		options += "," + m.Data
+	}
+	flags, data, err := mount.ParseTmpfsOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(","+data, ",size=") {
+		data = strings.TrimPrefix(data+",size=65536k", ",")
+	}
+	return &configs.Mount{
+		Source:      m.Source,
+		Destination: m.Destination,
+		Device:      "tmpfs",
+		Flags:       flags,
+		Data:        data,
+	}, nil
+}
+
 func (d *Driver) setupLabels(container *configs.Config, c *execdriver.Command) {
 	container.ProcessLabel = c.ProcessLabel
 	container.MountLabel = c.MountLabel
@@ -0,0 +1,258 @@
+package daemon
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/sara-nl/docker-1.9.1/api/types"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/parsers"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+)
+
+// imageGCCandidate is an image the garbage collector may remove, along
+// with the last time it was used by a container.
+type imageGCCandidate struct {
+	img      *image.Image
+	lastUsed time.Time
+}
+
+type byLastUsed []imageGCCandidate
+
+func (c byLastUsed) Len() int           { return len(c) }
+func (c byLastUsed) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c byLastUsed) Less(i, j int) bool { return c[i].lastUsed.Before(c[j].lastUsed) }
+
+// markImageUsed records that imgID was just used to start a container, so
+// that the garbage collector removes it only after the images that were not.
+// Every start counts, restarts included, but creating a container does not.
+func (daemon *Daemon) markImageUsed(imgID string) {
+	if imgID == "" {
+		return
+	}
+	if err := daemon.Graph().MarkUsed(imgID, time.Now()); err != nil {
+		logrus.Warnf("Unable to record last use of image %s: %v", stringid.TruncateID(imgID), err)
+	}
+}
+
+// verifyImageGCSettings validates the image garbage collector options of
+// the daemon configuration.
+func verifyImageGCSettings(config *Config) error {
+	if config.ImageGCHighThreshold < 0 || config.ImageGCHighThreshold > 100 {
+		return fmt.Errorf("image GC high threshold must be a percentage between 0 and 100, got %d", config.ImageGCHighThreshold)
+	}
+	if config.ImageGCLowThreshold < 0 || config.ImageGCLowThreshold > 100 {
+		return fmt.Errorf("image GC low threshold must be a percentage between 0 and 100, got %d", config.ImageGCLowThreshold)
+	}
+	if config.ImageGCHighThreshold > 0 && config.ImageGCLowThreshold > config.ImageGCHighThreshold {
+		return fmt.Errorf("image GC low threshold (%d) can not be above the high threshold (%d)", config.ImageGCLowThreshold, config.ImageGCHighThreshold)
+	}
+	if config.ImageGCInterval < 0 || config.ImageGCMaxAge < 0 {
+		return fmt.Errorf("image GC interval and max age can not be negative")
+	}
+	for _, pattern := range config.ImageGCKeep {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid image GC keep pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// imageGC periodically removes unused images according to the garbage
+// collection settings of the daemon, until shutdown is closed.
+func (daemon *Daemon) imageGC(interval time.Duration, shutdown <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+			daemon.collectImages()
+		}
+	}
+}
+
+// collectImages runs a single garbage collection. Images unused for longer
+// than the configured max age are removed first. Then, if the disk holding
+// the daemon root is filled above the high threshold, the least recently
+// used images are removed until usage drops below the low threshold.
+func (daemon *Daemon) collectImages() {
+	config := daemon.configStore
+
+	daemon.imageGCLock.Lock()
+	defer daemon.imageGCLock.Unlock()
+
+	if config.ImageGCMaxAge > 0 {
+		report := daemon.pruneImages(time.Now().Add(-config.ImageGCMaxAge), nil, nil)
+		logImageGCReport("age", report)
+	}
+
+	if config.ImageGCHighThreshold <= 0 {
+		return
+	}
+	usage, err := diskUsage(daemon.root)
+	if err != nil {
+		logrus.Warnf("image GC: unable to determine disk usage of %s: %v", daemon.root, err)
+		return
+	}
+	if usage < config.ImageGCHighThreshold {
+		return
+	}
+	logrus.Infof("image GC: disk usage of %s is %d%%, above the high threshold of %d%%", daemon.root, usage, config.ImageGCHighThreshold)
+	report := daemon.pruneImages(time.Time{}, nil, func() bool {
+		usage, err := diskUsage(daemon.root)
+		return err != nil || usage < config.ImageGCLowThreshold
+	})
+	logImageGCReport("disk pressure", report)
+}
+
+func logImageGCReport(reason string, report *types.ImagesPruneReport) {
+	if len(report.ImagesDeleted) == 0 {
+		return
+	}
+	logrus.Infof("image GC (%s): removed %d images and layers, reclaimed %d bytes", reason, len(report.ImagesDeleted), report.SpaceReclaimed)
+}
+
+// ImagesPrune removes all images that are not used by any container and
+// that have not been used for at least unusedFor. Images whose references
+// match one of the keep patterns, or one of the patterns configured on the
+// daemon, are left alone.
+func (daemon *Daemon) ImagesPrune(unusedFor time.Duration, keep []string) (*types.ImagesPruneReport, error) {
+	for _, pattern := range keep {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid keep pattern %q: %v", pattern, err)
+		}
+	}
+
+	daemon.imageGCLock.Lock()
+	defer daemon.imageGCLock.Unlock()
+
+	var olderThan time.Time
+	if unusedFor > 0 {
+		olderThan = time.Now().Add(-unusedFor)
+	}
+	return daemon.pruneImages(olderThan, keep, nil), nil
+}
+
+// pruneImages removes candidate images in least recently used order. Only
+// images last used before olderThan are removed, unless olderThan is zero.
+// If done is not nil, it is consulted before each removal and stops the
+// collection once it returns true. Errors removing an image are logged and
+// the image is skipped.
+func (daemon *Daemon) pruneImages(olderThan time.Time, keep []string, done func() bool) *types.ImagesPruneReport {
+	report := &types.ImagesPruneReport{ImagesDeleted: []types.ImageDelete{}}
+	keep = append(append([]string{}, keep...), daemon.configStore.ImageGCKeep...)
+	skipped := make(map[string]bool)
+
+	for {
+		removed := false
+		for _, c := range daemon.imageGCCandidates(keep) {
+			if skipped[c.img.ID] {
+				continue
+			}
+			if !olderThan.IsZero() && !c.lastUsed.Before(olderThan) {
+				break
+			}
+			if done != nil && done() {
+				return report
+			}
+			if err := daemon.removeImageForGC(c.img, report); err != nil {
+				logrus.Warnf("image GC: unable to remove image %s: %v", stringid.TruncateID(c.img.ID), err)
+				skipped[c.img.ID] = true
+				continue
+			}
+			removed = true
+		}
+		// Removing an image may turn its tagged parent into a new
+		// candidate, so keep going until nothing more can be removed.
+		if !removed {
+			return report
+		}
+	}
+}
+
+// imageGCCandidates returns the images without children that are not used by
+// any container, not held by a pull or build and not protected by one of the
+// keep patterns, sorted from least to most recently used.
+func (daemon *Daemon) imageGCCandidates(keep []string) []imageGCCandidate {
+	used := make(map[string]bool)
+	for _, container := range daemon.List() {
+		used[container.ImageID] = true
+	}
+	refs := daemon.repositories.ByID()
+
+	var candidates []imageGCCandidate
+	for id, img := range daemon.Graph().Heads() {
+		if used[id] || daemon.Graph().IsHeld(id) || matchesKeepPattern(refs[id], keep) {
+			continue
+		}
+		lastUsed, err := daemon.Graph().LastUsed(id)
+		if err != nil {
+			logrus.Warnf("image GC: unable to determine when image %s was last used: %v", stringid.TruncateID(id), err)
+			continue
+		}
+		candidates = append(candidates, imageGCCandidate{img: img, lastUsed: lastUsed})
+	}
+	sort.Sort(byLastUsed(candidates))
+	return candidates
+}
+
+// removeImageForGC untags and deletes img, pruning the parent images that are
+// left unused. The removed references and images are added to report.
+func (daemon *Daemon) removeImageForGC(img *image.Image, report *types.ImagesPruneReport) error {
+	if conflict := daemon.checkImageDeleteHardConflict(img); conflict != nil {
+		return conflict
+	}
+	if container := daemon.getContainerUsingImage(img.ID); container != nil {
+		return fmt.Errorf("image is being used by container %s", stringid.TruncateID(container.ID))
+	}
+
+	// Remember the sizes of the layers before they are gone.
+	sizes := make(map[string]int64)
+	for layer := img; layer != nil; {
+		sizes[layer.ID] = layer.Size
+		if layer.Parent == "" {
+			break
+		}
+		parent, err := daemon.Graph().Get(layer.Parent)
+		if err != nil {
+			break
+		}
+		layer = parent
+	}
+
+	records := []types.ImageDelete{}
+	err := daemon.removeAllReferencesToImageID(img.ID, &records)
+	if err == nil {
+		err = daemon.imageDeleteHelper(img, &records, false, true, false)
+	}
+	for _, record := range records {
+		if record.Deleted != "" && sizes[record.Deleted] > 0 {
+			report.SpaceReclaimed += uint64(sizes[record.Deleted])
+		}
+	}
+	report.ImagesDeleted = append(report.ImagesDeleted, records...)
+	return err
+}
+
+// matchesKeepPattern returns whether any of the repository references matches
+// one of the patterns. A pattern is matched against the full reference
+// ("busybox:latest") and against the repository name alone ("busybox").
+func matchesKeepPattern(refs []string, patterns []string) bool {
+	for _, ref := range refs {
+		repo, _ := parsers.ParseRepositoryTag(ref)
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, ref); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, repo); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sara-nl/docker-1.9.1/image"
+)
+
+func TestMatchesKeepPattern(t *testing.T) {
+	cases := []struct {
+		refs     []string
+		patterns []string
+		expected bool
+	}{
+		{nil, []string{"*"}, false},
+		{[]string{"busybox:latest"}, nil, false},
+		{[]string{"busybox:latest"}, []string{"busybox"}, true},
+		{[]string{"busybox:latest"}, []string{"busybox:*"}, true},
+		{[]string{"busybox:latest"}, []string{"busybox:1.*"}, false},
+		{[]string{"busybox:1.24", "ubuntu:14.04"}, []string{"ubuntu:*"}, true},
+		{[]string{"registry.example.com:5000/base/centos:7"}, []string{"registry.example.com:5000/base/*"}, true},
+		{[]string{"registry.example.com:5000/app:7"}, []string{"registry.example.com:5000/base/*"}, false},
+	}
+
+	for _, c := range cases {
+		if actual := matchesKeepPattern(c.refs, c.patterns); actual != c.expected {
+			t.Fatalf("Expected keep patterns %v to match %v: %v, got %v", c.patterns, c.refs, c.expected, actual)
+		}
+	}
+}
+
+func TestImageGCCandidateOrder(t *testing.T) {
+	now := time.Now()
+	candidates := []imageGCCandidate{
+		{img: &image.Image{ID: "recent"}, lastUsed: now},
+		{img: &image.Image{ID: "oldest"}, lastUsed: now.Add(-72 * time.Hour)},
+		{img: &image.Image{ID: "older"}, lastUsed: now.Add(-time.Hour)},
+	}
+	sort.Sort(byLastUsed(candidates))
+
+	for i, id := range []string{"oldest", "older", "recent"} {
+		if candidates[i].img.ID != id {
+			t.Fatalf("Expected candidate %d to be %s, got %s", i, id, candidates[i].img.ID)
+		}
+	}
+}
+
+func TestVerifyImageGCSettings(t *testing.T) {
+	valid := &Config{}
+	valid.ImageGCHighThreshold = 90
+	valid.ImageGCLowThreshold = 80
+	if err := verifyImageGCSettings(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(c *Config){
+		func(c *Config) { c.ImageGCHighThreshold = 101 },
+		func(c *Config) { c.ImageGCLowThreshold = -1 },
+		func(c *Config) { c.ImageGCLowThreshold = 95 },
+		func(c *Config) { c.ImageGCMaxAge = -time.Hour },
+		func(c *Config) { c.ImageGCKeep = []string{"busybox:["} },
+	}
+	for i, modify := range invalid {
+		config := &Config{}
+		config.ImageGCHighThreshold = 90
+		config.ImageGCLowThreshold = 80
+		modify(config)
+		if err := verifyImageGCSettings(config); err == nil {
+			t.Fatalf("Expected an error for invalid settings %d", i)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// +build !windows
+
+package daemon
+
+import "syscall"
+
+// diskUsage returns the percentage of the filesystem holding path that is in
+// use, counting the blocks reserved for root as used.
+func diskUsage(path string) (int, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	if st.Blocks == 0 {
+		return 0, nil
+	}
+	return int((uint64(st.Blocks) - uint64(st.Bavail)) * 100 / uint64(st.Blocks)), nil
+}
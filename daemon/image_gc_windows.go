@@ -0,0 +1,9 @@
+package daemon
+
+import "errors"
+
+// diskUsage is not supported on Windows, so images are never removed because
+// of disk pressure.
+func diskUsage(path string) (int, error) {
+	return 0, errors.New("disk usage is not supported on Windows")
+}
@@ -3,6 +3,8 @@
 package daemon
 
 import (
+	"strings"
+
 	"github.com/sara-nl/docker-1.9.1/api/types"
 	"github.com/sara-nl/docker-1.9.1/api/types/versions/v1p19"
 )
@@ -67,5 +69,28 @@ func addMountPoints(container *Container) []types.MountPoint {
 			RW:          m.RW,
 		})
 	}
+	for dest, data := range container.hostConfig.Tmpfs {
+		mountPoints = append(mountPoints, types.MountPoint{
+			Source:      "tmpfs",
+			Destination: dest,
+			Mode:        data,
+			RW:          !tmpfsReadOnly(data),
+		})
+	}
 	return mountPoints
 }
+
+// tmpfsReadOnly returns whether the options of a tmpfs mount make it read
+// only, the last of ro and rw wins.
+func tmpfsReadOnly(data string) bool {
+	ro := false
+	for _, opt := range strings.Split(data, ",") {
+		switch opt {
+		case "ro":
+			ro = true
+		case "rw":
+			ro = false
+		}
+	}
+	return ro
+}
@@ -35,6 +35,13 @@ const (
 // errStopIteration makes the iterator to stop without returning an error.
 var errStopIteration = errors.New("container list iteration stopped")
 
+// acceptedVolumeFilterTags are the filters accepted when listing volumes.
+var acceptedVolumeFilterTags = map[string]bool{
+	"dangling": true,
+	"driver":   true,
+	"label":    true,
+}
+
 // List returns an array of all containers registered in the daemon.
 func (daemon *Daemon) List() []*Container {
 	return daemon.containers.List()
@@ -379,13 +386,19 @@ func (daemon *Daemon) transformContainer(container *Container, ctx *listContext)
 }
 
 // Volumes lists known volumes, using the filter to restrict the range
-// of volumes returned.
-func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, error) {
+// of volumes returned. The usage of the volumes is only computed when
+// size is true.
+func (daemon *Daemon) Volumes(filter string, size bool) ([]*types.Volume, error) {
 	var volumesOut []*types.Volume
 	volFilters, err := filters.FromParam(filter)
 	if err != nil {
 		return nil, err
 	}
+	for name := range volFilters {
+		if _, ok := acceptedVolumeFilterTags[name]; !ok {
+			return nil, fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
 
 	filterUsed := false
 	if i, ok := volFilters["dangling"]; ok {
@@ -404,7 +417,17 @@ func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, error) {
 		if filterUsed && daemon.volumes.Count(v) > 0 {
 			continue
 		}
-		volumesOut = append(volumesOut, volumeToAPIType(v))
+		if !matchVolumeDriver(volFilters["driver"], v.DriverName()) {
+			continue
+		}
+		if !volFilters.MatchKVList("label", daemon.volumes.Labels(v)) {
+			continue
+		}
+		apiV := daemon.volumeToAPIType(v)
+		if size {
+			apiV.Usage = daemon.volumeUsage(v)
+		}
+		volumesOut = append(volumesOut, apiV)
 	}
 	return volumesOut, nil
 }
@@ -419,3 +442,17 @@ func populateImageFilterByParents(ancestorMap map[string]bool, imageID string, b
 		ancestorMap[imageID] = true
 	}
 }
+
+// matchVolumeDriver returns true if no driver is given or if the driver name
+// is one of the given drivers.
+func matchVolumeDriver(drivers []string, name string) bool {
+	if len(drivers) == 0 {
+		return true
+	}
+	for _, d := range drivers {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
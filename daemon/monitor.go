@@ -263,6 +263,9 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	}
 
 	m.container.setRunning(pid)
+	// Every start of the process counts as a use of the image, including
+	// restarts and the starts of containers restored by the daemon.
+	m.container.daemon.markImageUsed(m.container.ImageID)
 
 	// signal that the process has started
 	// close channel only if not closed
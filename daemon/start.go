@@ -3,6 +3,7 @@ package daemon
 import (
 	"runtime"
 
+	"github.com/docker/distribution/registry/api/errcode"
 	derr "github.com/sara-nl/docker-1.9.1/errors"
 	"github.com/sara-nl/docker-1.9.1/runconfig"
 	"github.com/sara-nl/docker-1.9.1/utils"
@@ -45,6 +46,10 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *runconfig.HostConf
 	}
 
 	if err := container.Start(); err != nil {
+		// the conflict is reported as is, it is not an error of the daemon
+		if e, ok := err.(errcode.Error); ok && e.ErrorCode() == derr.ErrorCodeVolumeLocked {
+			return err
+		}
 		return derr.ErrorCodeCantStart.WithArgs(name, utils.GetErrorMessage(err))
 	}
 
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"io"
+
+	"github.com/sara-nl/docker-1.9.1/api/types"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+	"github.com/sara-nl/docker-1.9.1/pkg/chrootarchive"
+	"github.com/sara-nl/docker-1.9.1/pkg/ioutils"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
+
+// VolumeExport returns the content of the volume with the given name as a
+// tar archive. The volume stays mounted until the archive is closed.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeExport(name string) (io.ReadCloser, error) {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the volume from being removed while it is exported
+	daemon.volumes.Increment(v)
+
+	dir, release, err := mountVolumeOnHost(v, false)
+	if err != nil {
+		daemon.volumes.Decrement(v)
+		return nil, derr.ErrorCodeExportFailed.WithArgs(name, err)
+	}
+
+	data, err := archive.TarWithOptions(dir, &archive.TarOptions{
+		Compression: archive.Uncompressed,
+		UIDMaps:     daemon.uidMaps,
+		GIDMaps:     daemon.gidMaps,
+	})
+	if err != nil {
+		release()
+		daemon.volumes.Decrement(v)
+		return nil, derr.ErrorCodeExportFailed.WithArgs(name, err)
+	}
+	return ioutils.NewReadCloserWrapper(data, func() error {
+		err := data.Close()
+		release()
+		daemon.volumes.Decrement(v)
+		return err
+	}), nil
+}
+
+// VolumeImport extracts the tar archive read from the given reader into
+// the volume with the given name, creating it with the given driver, opts
+// and labels if it doesn't exist. Archives can't be imported into volumes
+// in use by containers, whose processes would see the files change under
+// them.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeImport(name, driverName string, opts, labels map[string]string, in io.Reader) (*types.Volume, error) {
+	if name == "" {
+		name = stringid.GenerateNonCryptoID()
+	}
+
+	v, err := daemon.volumes.Create(name, driverName, opts, labels)
+	if err != nil {
+		return nil, err
+	}
+	if (driverName != "" && v.DriverName() != driverName) || (driverName == "" && v.DriverName() != volume.DefaultDriverName) {
+		return nil, derr.ErrorVolumeNameTaken.WithArgs(name, v.DriverName())
+	}
+
+	// only import into a volume no container uses, and hold a reference so that
+	// the volume cannot be removed while it is imported
+	if err := daemon.volumes.IncrementIfUnused(v); err != nil {
+		return nil, derr.ErrorCodeVolumeImportInUse.WithArgs(name)
+	}
+	defer daemon.volumes.Decrement(v)
+
+	dir, release, err := mountVolumeOnHost(v, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := chrootarchive.Untar(in, dir, &archive.TarOptions{
+		UIDMaps: daemon.uidMaps,
+		GIDMaps: daemon.gidMaps,
+	}); err != nil {
+		return nil, err
+	}
+	return daemon.volumeToAPIType(v), nil
+}
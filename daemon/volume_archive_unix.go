@@ -0,0 +1,78 @@
+// +build !windows
+
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
+
+// mountVolumeOnHost mounts the volume and returns a directory on the host
+// with its content, along with the function to call once the directory is
+// no longer used. The volume is mounted read-only unless rw is set. Volumes
+// backed by a block device are mounted on a temporary directory.
+func mountVolumeOnHost(v volume.Volume, rw bool) (string, func(), error) {
+	// the daemon mounts the volume itself, not for a container
+	id := stringid.GenerateNonCryptoID()
+	path, err := acquireVolume(v, id, "", rw)
+	if err != nil {
+		return "", nil, err
+	}
+	unmountVolume := func() {
+		if err := releaseVolume(v, id, ""); err != nil {
+			logrus.Errorf("Error unmounting volume %s: %v", v.Name(), err)
+		}
+	}
+
+	dm, ok := v.(volume.DeviceMounter)
+	if !ok {
+		return path, unmountVolume, nil
+	}
+	fsType, data := dm.MountType()
+	if fsType == "" {
+		return path, unmountVolume, nil
+	}
+
+	dir, err := ioutil.TempDir("", "docker-volume-"+v.DriverName())
+	if err != nil {
+		unmountVolume()
+		return "", nil, err
+	}
+	// the mount command is used as the filesystem type may be "auto"
+	args := []string{"-t", fsType}
+	if !rw {
+		if data != "" {
+			data += ","
+		}
+		data += "ro"
+	}
+	if data != "" {
+		args = append(args, "-o", data)
+	}
+	cmd := exec.Command("mount", append(args, path, dir)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(dir)
+		unmountVolume()
+		return "", nil, fmt.Errorf("Error mounting %s of volume %s: %s - %s", path, v.Name(), err, strings.TrimRight(stderr.String(), "\n"))
+	}
+
+	return dir, func() {
+		if err := mount.Unmount(dir); err != nil {
+			logrus.Errorf("Error unmounting volume %s from %s: %v", v.Name(), dir, err)
+		} else {
+			os.Remove(dir)
+		}
+		unmountVolume()
+	}, nil
+}
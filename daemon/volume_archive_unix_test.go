@@ -0,0 +1,141 @@
+// +build !windows
+
+package daemon
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+	"github.com/sara-nl/docker-1.9.1/pkg/reexec"
+	"github.com/sara-nl/docker-1.9.1/volume"
+	volumedrivers "github.com/sara-nl/docker-1.9.1/volume/drivers"
+)
+
+func init() {
+	// archives are extracted into volumes by a re-executed binary
+	reexec.Init()
+}
+
+func TestVolumeExportImport(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-volume-archive-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	daemon, err := initDaemonWithVolumeStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer volumedrivers.Unregister(volume.DefaultDriverName)
+
+	src, err := daemon.volumes.Create("src", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(src.Path(), "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src.Path(), "dir", "file"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := daemon.VolumeExport("src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := daemon.volumes.Count(src); count != 1 {
+		t.Fatalf("Expected the exported volume to be referenced while exported, got %d references", count)
+	}
+	if _, err := daemon.VolumeImport("dst", "", nil, nil, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := daemon.volumes.Get("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dst.Path(), "dir", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "content" {
+		t.Fatalf("Expected the imported file to hold %q, got %q", "content", content)
+	}
+	for _, v := range []volume.Volume{src, dst} {
+		if count := daemon.volumes.Count(v); count != 0 {
+			t.Fatalf("Expected volume %s not to be referenced any more, got %d references", v.Name(), count)
+		}
+	}
+}
+
+func TestVolumeImportInUse(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-volume-archive-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	daemon, err := initDaemonWithVolumeStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer volumedrivers.Unregister(volume.DefaultDriverName)
+
+	v, err := daemon.volumes.Create("inuse", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(v.Path(), "file"), []byte("in use"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srcDir := filepath.Join(tmp, "archive")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(srcDir, "file"), []byte("imported"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	arch, err := archive.Tar(srcDir, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball, err := ioutil.ReadAll(arch)
+	arch.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a container using the volume
+	daemon.volumes.Increment(v)
+	if _, err := daemon.VolumeImport("inuse", "", nil, nil, bytes.NewReader(tarball)); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Fatalf("Expected importing into a volume in use to fail, got %v", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(v.Path(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "in use" {
+		t.Fatalf("Expected the volume in use to be left alone, got %q", content)
+	}
+	if count := daemon.volumes.Count(v); count != 1 {
+		t.Fatalf("Expected the refused import to leave the references alone, got %d", count)
+	}
+
+	daemon.volumes.Decrement(v)
+	if _, err := daemon.VolumeImport("inuse", "", nil, nil, bytes.NewReader(tarball)); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(v.Path(), "file")); string(content) != "imported" {
+		t.Fatalf("Expected the unused volume to be imported into, got %q", content)
+	}
+}
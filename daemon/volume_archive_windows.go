@@ -0,0 +1,26 @@
+// +build windows
+
+package daemon
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
+
+// mountVolumeOnHost mounts the volume and returns the directory with its
+// content, along with the function to call once the directory is no
+// longer used. rw tells whether the content is going to be modified.
+func mountVolumeOnHost(v volume.Volume, rw bool) (string, func(), error) {
+	// the daemon mounts the volume itself, not for a container
+	id := stringid.GenerateNonCryptoID()
+	path, err := acquireVolume(v, id, "", rw)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, func() {
+		if err := releaseVolume(v, id, ""); err != nil {
+			logrus.Errorf("Error unmounting volume %s: %v", v.Name(), err)
+		}
+	}, nil
+}
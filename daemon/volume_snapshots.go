@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"github.com/sara-nl/docker-1.9.1/api/types"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
+
+// VolumeSnapshotCreate takes a snapshot with the given name of a volume.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshotCreate(volumeName, name string) (*types.VolumeSnapshot, error) {
+	s, err := daemon.volumeSnapshotter(volumeName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CreateSnapshot(name); err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snapshots {
+		if snap.Name == name {
+			return snapshotToAPIType(snap), nil
+		}
+	}
+	return &types.VolumeSnapshot{Name: name}, nil
+}
+
+// VolumeSnapshots lists the snapshots of a volume.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshots(volumeName string) ([]*types.VolumeSnapshot, error) {
+	s, err := daemon.volumeSnapshotter(volumeName)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := s.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.VolumeSnapshot, 0, len(snapshots))
+	for _, snap := range snapshots {
+		out = append(out, snapshotToAPIType(snap))
+	}
+	return out, nil
+}
+
+// VolumeSnapshotRm removes the snapshot with the given name of a volume.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshotRm(volumeName, name string) error {
+	s, err := daemon.volumeSnapshotter(volumeName)
+	if err != nil {
+		return err
+	}
+	return s.RemoveSnapshot(name)
+}
+
+// VolumeSnapshotClone creates a volume from the snapshot of a volume, using
+// the driver of that volume.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshotClone(volumeName, name, cloneName string, opts, labels map[string]string) (*types.Volume, error) {
+	v, err := daemon.volumes.Get(volumeName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(volume.Snapshotter); !ok {
+		return nil, derr.ErrorCodeVolumeNoSnapshots.WithArgs(volumeName, v.DriverName())
+	}
+
+	if cloneName == "" {
+		cloneName = stringid.GenerateNonCryptoID()
+	}
+	if existing, err := daemon.volumes.Get(cloneName); err == nil {
+		return nil, derr.ErrorVolumeNameTaken.WithArgs(cloneName, existing.DriverName())
+	}
+
+	cloneOpts := map[string]string{volume.SnapshotOpt: volumeName + "@" + name}
+	for k, val := range opts {
+		if k != volume.SnapshotOpt {
+			cloneOpts[k] = val
+		}
+	}
+	clone, err := daemon.volumes.Create(cloneName, v.DriverName(), cloneOpts, labels)
+	if err != nil {
+		return nil, err
+	}
+	return daemon.volumeToAPIType(clone), nil
+}
+
+// volumeSnapshotter returns the volume with the given name if its driver
+// supports snapshots.
+func (daemon *Daemon) volumeSnapshotter(name string) (volume.Snapshotter, error) {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(volume.Snapshotter)
+	if !ok {
+		return nil, derr.ErrorCodeVolumeNoSnapshots.WithArgs(name, v.DriverName())
+	}
+	return s, nil
+}
+
+// snapshotToAPIType converts a volume.Snapshot to the type used by the remote API
+func snapshotToAPIType(s volume.Snapshot) *types.VolumeSnapshot {
+	return &types.VolumeSnapshot{
+		Name: s.Name,
+		Size: s.Size,
+	}
+}
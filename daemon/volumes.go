@@ -12,8 +12,10 @@ import (
 	"github.com/sara-nl/docker-1.9.1/api/types"
 	derr "github.com/sara-nl/docker-1.9.1/errors"
 	"github.com/sara-nl/docker-1.9.1/pkg/chrootarchive"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
 	"github.com/sara-nl/docker-1.9.1/pkg/system"
 	"github.com/sara-nl/docker-1.9.1/volume"
+	"github.com/sara-nl/docker-1.9.1/volume/drivers"
 )
 
 var (
@@ -33,13 +35,38 @@ type mountPoint struct {
 	Volume      volume.Volume `json:"-"`
 	Source      string
 	Mode        string `json:"Relabel"` // Originally field was `Relabel`"
+
+	// ID is the identifier the volume is mounted with, it is kept until
+	// the volume is unmounted so the mount can be released by a daemon
+	// restarted after an unclean shutdown.
+	ID string `json:",omitempty"`
+
+	// copyID is the identifier of the transient mount of the volume made
+	// to copy files from or to the container.
+	copyID string
 }
 
 // Setup sets up a mount point by either mounting the volume if it is
-// configured, or creating the source directory if supplied.
-func (m *mountPoint) Setup() (string, error) {
+// configured, or creating the source directory if supplied. The volume
+// is mounted on behalf of the container with the given ID. A transient
+// mount, made to copy files, gets a mount ID of its own so that it leaves
+// the mount of the running container untouched.
+func (m *mountPoint) Setup(containerID string, transient bool) (string, error) {
 	if m.Volume != nil {
-		return m.Volume.Mount()
+		id := m.ID
+		if transient || id == "" {
+			id = stringid.GenerateNonCryptoID()
+		}
+		path, err := acquireVolume(m.Volume, id, containerID, m.RW)
+		if err != nil {
+			return "", err
+		}
+		if transient {
+			m.copyID = id
+		} else {
+			m.ID = id
+		}
+		return path, nil
 	}
 
 	if len(m.Source) > 0 {
@@ -58,6 +85,37 @@ func (m *mountPoint) Setup() (string, error) {
 	return "", derr.ErrorCodeMountSetup
 }
 
+// acquireVolume mounts the volume with the given mount id, after locking
+// the access to it if the volume can only be used read-write by a single
+// mount at a time.
+func acquireVolume(v volume.Volume, id, containerID string, rw bool) (string, error) {
+	l, isLocker := v.(volume.AccessLocker)
+	if isLocker {
+		if err := l.LockAccess(id, containerID, rw); err != nil {
+			return "", err
+		}
+	}
+	path, err := v.Mount(id, containerID)
+	if err != nil && isLocker {
+		if err := l.UnlockAccess(id); err != nil {
+			logrus.Errorf("Error releasing volume %s: %v", v.Name(), err)
+		}
+	}
+	return path, err
+}
+
+// releaseVolume unmounts a volume mounted with acquireVolume and releases
+// the access lock of the mount.
+func releaseVolume(v volume.Volume, id, containerID string) error {
+	if err := v.Unmount(id, containerID); err != nil {
+		return err
+	}
+	if l, ok := v.(volume.AccessLocker); ok {
+		return l.UnlockAccess(id)
+	}
+	return nil
+}
+
 // hasResource checks whether the given absolute path for a container is in
 // this mount point. If the relative path starts with `../` then the resource
 // is outside of this mount point, but we can't simply check for this prefix
@@ -103,10 +161,50 @@ func copyExistingContents(source, destination string) error {
 }
 
 // volumeToAPIType converts a volume.Volume to the type used by the remote API
-func volumeToAPIType(v volume.Volume) *types.Volume {
+func (daemon *Daemon) volumeToAPIType(v volume.Volume) *types.Volume {
 	return &types.Volume{
 		Name:       v.Name(),
 		Driver:     v.DriverName(),
 		Mountpoint: v.Path(),
+		Labels:     daemon.volumes.Labels(v),
+		Scope:      volumeScope(v),
+	}
+}
+
+// volumeScope returns the scope of the driver of the volume, or an empty
+// string if the driver isn't available.
+func volumeScope(v volume.Volume) string {
+	d, err := volumedrivers.GetDriver(v.DriverName())
+	if err != nil {
+		return ""
+	}
+	return d.Scope()
+}
+
+// volumeUsage returns the space used by a volume and the containers
+// referencing it. The size is -1 when the driver of the volume can't
+// report it.
+func (daemon *Daemon) volumeUsage(v volume.Volume) *types.VolumeUsage {
+	usage := &types.VolumeUsage{
+		Size:       -1,
+		RefCount:   int(daemon.volumes.Count(v)),
+		Containers: daemon.volumeReferences(v),
+	}
+	if r, ok := v.(volume.UsageReporter); ok {
+		size, err := r.Usage()
+		if err != nil {
+			logrus.Debugf("Unable to get the usage of volume %s: %v", v.Name(), err)
+		} else {
+			usage.Size = size
+		}
+	}
+	if r, ok := v.(volume.CapacityReporter); ok {
+		capacity, err := r.Capacity()
+		if err != nil {
+			logrus.Debugf("Unable to get the capacity of volume %s: %v", v.Name(), err)
+		} else {
+			usage.Capacity = capacity
+		}
 	}
+	return usage
 }
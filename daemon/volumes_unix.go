@@ -39,24 +39,30 @@ func copyOwnership(source, destination string) error {
 // setupMounts iterates through each of the mount points for a container and
 // calls Setup() on each. It also looks to see if is a network mount such as
 // /etc/resolv.conf, and if it is not, appends it to the array of mounts.
-func (container *Container) setupMounts() ([]execdriver.Mount, error) {
+// The volumes are mounted transiently if transient is set.
+func (container *Container) setupMounts(transient bool) ([]execdriver.Mount, error) {
 	var mounts []execdriver.Mount
 	for _, m := range container.MountPoints {
-		path, err := m.Setup()
+		path, err := m.Setup(container.ID, transient)
 		if err != nil {
 			return nil, err
 		}
 		if !container.trySetNetworkMount(m.Destination, path) {
-			mounts = append(mounts, execdriver.Mount{
-				Source:      path, // Note that for Ceph volumes, this will be the mapped device (e.g. /dev/rbd0), and for NFS shares, it will be the share URI (e.g. 1.2.3.4://foo)
+			mnt := execdriver.Mount{
+				Source:      path,
 				Destination: m.Destination,
 				Writable:    m.RW,
-				Driver:	     m.Driver,
-			})
+			}
+			// Volumes backed by a block device or network filesystem are
+			// mounted into the container instead of being bind mounted.
+			if dm, ok := m.Volume.(volume.DeviceMounter); ok {
+				mnt.FsType, mnt.Data = dm.MountType()
+			}
+			mounts = append(mounts, mnt)
 		}
 	}
 
-	mounts = sortMounts(mounts)
+	mounts = sortMounts(append(mounts, container.tmpfsMounts()...))
 	netMounts := container.networkMounts()
 	// if we are going to mount any of the network files from container
 	// metadata, the ownership must be set properly for potential container
@@ -70,6 +76,23 @@ func (container *Container) setupMounts() ([]execdriver.Mount, error) {
 	return append(mounts, netMounts...), nil
 }
 
+// tmpfsMounts returns the tmpfs mounts requested for the container. The
+// tmpfs filesystems are not executable by default, like /dev/shm, and are
+// 64MB large unless the options say otherwise.
+func (container *Container) tmpfsMounts() []execdriver.Mount {
+	var mounts []execdriver.Mount
+	for dest, data := range container.hostConfig.Tmpfs {
+		mounts = append(mounts, execdriver.Mount{
+			Source:      "tmpfs",
+			Destination: dest,
+			Writable:    true,
+			FsType:      "tmpfs",
+			Data:        data,
+		})
+	}
+	return mounts
+}
+
 // parseBindMount validates the configuration of mount information in runconfig is valid.
 func parseBindMount(spec, volumeDriver string) (*mountPoint, error) {
 	bind := &mountPoint{
@@ -109,8 +132,6 @@ func parseBindMount(spec, volumeDriver string) (*mountPoint, error) {
 			} else {
 				bind.Driver = volumeDriver
 			}
-		} else if bind.Driver == "nfs" {
-			name = strings.Replace(name, "//", "://", 1)
 		}
 	} else {
 		bind.Source = filepath.Clean(source)
@@ -142,11 +163,12 @@ func parseMountMode(mode string) (bool, string, string, error) {
 			}
 			sharingSpecified = true
 			labelItems = append(labelItems, item)
-		case "ceph", "nfs":
-			if driver != "" {
+		default:
+			name, ok := volume.ParseMountType(item)
+			if !ok || driver != "" {
 				return false, "", "", fmt.Errorf("invalid mode for volumes: %s", mode)
 			}
-			driver = item
+			driver = name
 		}
 	}
 	return rw, strings.Join(labelItems, ","), driver, nil
@@ -354,6 +376,10 @@ func (daemon *Daemon) registerMountPoints(container *Container, hostConfig *runc
 		}
 
 		for _, m := range c.MountPoints {
+			// a tmpfs mounted on the same destination takes precedence
+			if _, exists := hostConfig.Tmpfs[m.Destination]; exists {
+				continue
+			}
 			cp := &mountPoint{
 				Name:        m.Name,
 				Source:      m.Source,
@@ -385,6 +411,9 @@ func (daemon *Daemon) registerMountPoints(container *Container, hostConfig *runc
 		if binds[bind.Destination] {
 			return derr.ErrorCodeVolumeDup.WithArgs(bind.Destination)
 		}
+		if _, exists := hostConfig.Tmpfs[bind.Destination]; exists {
+			return derr.ErrorCodeVolumeDup.WithArgs(bind.Destination)
+		}
 
 		if len(bind.Name) > 0 && len(bind.Driver) > 0 {
 			// create the volume
@@ -435,9 +464,25 @@ func (daemon *Daemon) registerMountPoints(container *Container, hostConfig *runc
 	return nil
 }
 
+// volumeReferences returns the IDs of the containers referencing the volume.
+func (daemon *Daemon) volumeReferences(v volume.Volume) []string {
+	var ids []string
+	for _, c := range daemon.List() {
+		c.Lock()
+		for _, m := range c.MountPoints {
+			if m.Name == v.Name() && m.Driver == v.DriverName() {
+				ids = append(ids, c.ID)
+				break
+			}
+		}
+		c.Unlock()
+	}
+	return ids
+}
+
 // createVolume creates a volume.
 func (daemon *Daemon) createVolume(name, driverName string, opts map[string]string) (volume.Volume, error) {
-	v, err := daemon.volumes.Create(name, driverName, opts)
+	v, err := daemon.volumes.Create(name, driverName, opts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,153 @@
+// +build !windows
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/runconfig"
+)
+
+// mountRecordingVolume is a volume that records the IDs it is mounted with.
+type mountRecordingVolume struct {
+	mounts map[string]bool
+}
+
+func (v *mountRecordingVolume) Name() string       { return "recording" }
+func (v *mountRecordingVolume) DriverName() string { return "recording" }
+func (v *mountRecordingVolume) Path() string       { return "/recording" }
+
+func (v *mountRecordingVolume) Mount(id, containerID string) (string, error) {
+	v.mounts[id] = true
+	return "/recording", nil
+}
+
+func (v *mountRecordingVolume) Unmount(id, containerID string) error {
+	delete(v.mounts, id)
+	return nil
+}
+
+// lockingVolume is a volume that can be used read-write by a single
+// container at a time.
+type lockingVolume struct {
+	mountRecordingVolume
+	holders map[string]string // container ID by mount ID
+}
+
+func (v *lockingVolume) LockAccess(id, containerID string, rw bool) error {
+	for holderID, holder := range v.holders {
+		if holderID != id && holder != containerID {
+			return derr.ErrorCodeVolumeLocked.WithArgs(v.Name(), "read-write", "container "+holder)
+		}
+	}
+	v.holders[id] = containerID
+	return nil
+}
+
+func (v *lockingVolume) UnlockAccess(id string) error {
+	delete(v.holders, id)
+	return nil
+}
+
+func TestCopyToRunningContainerWithLockedVolume(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-volumes-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	v := &lockingVolume{
+		mountRecordingVolume: mountRecordingVolume{mounts: make(map[string]bool)},
+		holders:              make(map[string]string),
+	}
+	m := &mountPoint{Destination: "/data", RW: true, Volume: v}
+	container := &Container{
+		CommonContainer: CommonContainer{
+			ID:         "testcontainer",
+			basefs:     tmp,
+			hostConfig: &runconfig.HostConfig{},
+		},
+		MountPoints: map[string]*mountPoint{"/data": m},
+	}
+
+	// locked and mounted by the start of the container
+	if _, err := m.Setup(container.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// docker cp mounts the volume again for the same container
+	if _, err := m.Setup(container.ID, true); err != nil {
+		t.Fatalf("Expected the volume of the running container to be mounted for a copy, got %v", err)
+	}
+	if err := container.unmountVolumes(true); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.holders) != 1 || v.holders[m.ID] != container.ID {
+		t.Fatalf("Expected the container to keep its lock on the volume, got %v", v.holders)
+	}
+
+	// other containers are still refused access
+	other := &mountPoint{Destination: "/data", RW: true, Volume: v}
+	if _, err := other.Setup("othercontainer", false); err == nil {
+		t.Fatal("Expected another container to be refused access to the locked volume")
+	}
+}
+
+func TestCopyLeavesRunningMountIntact(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-volumes-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	v := &mountRecordingVolume{mounts: make(map[string]bool)}
+	m := &mountPoint{Destination: "/data", RW: true, Volume: v}
+	container := &Container{
+		CommonContainer: CommonContainer{
+			ID:         "testcontainer",
+			basefs:     tmp,
+			hostConfig: &runconfig.HostConfig{},
+		},
+		MountPoints: map[string]*mountPoint{"/data": m},
+	}
+
+	// mounted by the start of the container
+	if _, err := m.Setup(container.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	liveID := m.ID
+	if liveID == "" || !v.mounts[liveID] {
+		t.Fatalf("Expected the volume to be mounted for the container, got ID %q and mounts %v", liveID, v.mounts)
+	}
+
+	// mounted and unmounted again by docker cp
+	if _, err := m.Setup(container.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != liveID {
+		t.Fatalf("Expected the mount ID of the container to stay %s, got %s", liveID, m.ID)
+	}
+	if len(v.mounts) != 2 {
+		t.Fatalf("Expected the volume to be mounted twice with different IDs, got %v", v.mounts)
+	}
+	if err := container.unmountVolumes(true); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.mounts) != 1 || !v.mounts[liveID] {
+		t.Fatalf("Expected the mount of the container to be intact, got %v", v.mounts)
+	}
+
+	// unmounted by the cleanup of the stopped container
+	if err := container.unmountVolumes(false); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.mounts) != 0 {
+		t.Fatalf("Expected the volume to be unmounted, got %v", v.mounts)
+	}
+	if m.ID != "" {
+		t.Fatalf("Expected the mount ID to be reset, got %s", m.ID)
+	}
+}
@@ -5,6 +5,7 @@ package daemon
 import (
 	"github.com/sara-nl/docker-1.9.1/daemon/execdriver"
 	"github.com/sara-nl/docker-1.9.1/runconfig"
+	"github.com/sara-nl/docker-1.9.1/volume"
 )
 
 // copyOwnership copies the permissions and group of a source file to the
@@ -18,7 +19,7 @@ func copyOwnership(source, destination string) error {
 // container and calls Setup() on each. It also looks to see if is a network
 // mount such as /etc/resolv.conf, and if it is not, appends it to the array
 // of mounts. As Windows does not support mount points, this is a no-op.
-func (container *Container) setupMounts() ([]execdriver.Mount, error) {
+func (container *Container) setupMounts(transient bool) ([]execdriver.Mount, error) {
 	return nil, nil
 }
 
@@ -28,6 +29,12 @@ func (daemon *Daemon) verifyVolumesInfo(container *Container) error {
 	return nil
 }
 
+// volumeReferences returns the IDs of the containers referencing the
+// volume. Windows does not support volumes.
+func (daemon *Daemon) volumeReferences(v volume.Volume) []string {
+	return nil
+}
+
 // registerMountPoints initializes the container mount points with the
 // configured volumes and bind mounts. Windows does not support volumes or
 // mount points.
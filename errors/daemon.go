@@ -872,6 +872,15 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
 
+	// ErrorCodeVolumeImportInUse is generated when an archive is imported
+	// into a volume that is in use.
+	ErrorCodeVolumeImportInUse = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUMEIMPORTINUSE",
+		Message:        "Conflict: volume %s is in use, remove the containers using it before importing into it",
+		Description:    "An archive can only be imported into a volume that is not used by any container",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
 	// ErrorVolumeNameTaken is generated when an error occurred while
 	// trying to create a volume that has existed using different driver.
 	ErrorVolumeNameTaken = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -880,4 +889,68 @@ var (
 		Description:    "An attempt to create a volume using a driver but the volume already exists with a different driver",
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
+
+	// ErrorCodeVolumeOptUnknown is generated when a volume driver is
+	// given an option it does not understand.
+	ErrorCodeVolumeOptUnknown = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_OPT_UNKNOWN",
+		Message:        "unknown option %q for the %s volume driver",
+		Description:    "An option was passed to a volume driver that the driver does not support",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeVolumeOptInvalid is generated when a volume driver option
+	// has a value the driver can not use.
+	ErrorCodeVolumeOptInvalid = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_OPT_INVALID",
+		Message:        "invalid value %q for option %q of the %s volume driver: %s",
+		Description:    "A volume driver option was given a value that is not valid for that driver",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeVolumeNoSnapshots is generated when a snapshot operation is
+	// requested for a volume whose driver does not support snapshots.
+	ErrorCodeVolumeNoSnapshots = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_NO_SNAPSHOTS",
+		Message:        "volume %s uses the %s driver, which does not support snapshots",
+		Description:    "A snapshot operation was requested for a volume whose driver does not support snapshots",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeVolumeSnapshotName is generated when the name of a volume
+	// snapshot isn't valid.
+	ErrorCodeVolumeSnapshotName = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_SNAPSHOT_NAME_INVALID",
+		Message:        "%s includes invalid characters for a snapshot name, only %s are allowed",
+		Description:    "The name of the volume snapshot is invalid",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeVolumeNoResize is generated when a resize is requested for a
+	// volume whose driver does not support resizing.
+	ErrorCodeVolumeNoResize = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_NO_RESIZE",
+		Message:        "volume %s uses the %s driver, which does not support resizing",
+		Description:    "A resize was requested for a volume whose driver does not support resizing",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeVolumeShrink is generated when a volume is resized to a size
+	// smaller than its current size.
+	ErrorCodeVolumeShrink = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_SHRINK",
+		Message:        "volume %s can not be shrunk from %s to %s",
+		Description:    "Volumes can only be resized to a size larger than their current size",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeVolumeLocked is generated when a volume that can only be
+	// mounted read-write by a single consumer is mounted in a mode that
+	// conflicts with the mounts that already use it.
+	ErrorCodeVolumeLocked = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "VOLUME_LOCKED",
+		Message:        "volume %s can not be mounted %s, it is in use by %s",
+		Description:    "The volume can be mounted read-write by a single consumer, or read-only by many, and is already in use",
+		HTTPStatusCode: http.StatusConflict,
+	})
 )
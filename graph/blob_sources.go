@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/digest"
+	"github.com/sara-nl/docker-1.9.1/pkg/idtools"
+)
+
+// maxBlobSources is the number of repositories remembered for a blob.
+const maxBlobSources = 5
+
+// blobSourcesDir is the directory in the graph root holding the sources of
+// blobs, in a file per blob digest.
+const blobSourcesDir = "_blobsources"
+
+// blobSource is a repository of a registry known to hold a layer blob,
+// because the blob was pushed to or pulled from it.
+type blobSource struct {
+	Registry   string
+	Repository string
+}
+
+// AddBlobSource records that the blob dgst was pushed to or pulled from the
+// repository of the given registry. The most recently added sources come
+// first.
+func (graph *Graph) AddBlobSource(dgst digest.Digest, registryName, repository string) error {
+	if err := dgst.Validate(); err != nil {
+		return err
+	}
+	graph.blobSourcesMutex.Lock(dgst.String())
+	defer graph.blobSourcesMutex.Unlock(dgst.String())
+
+	sources, err := graph.getBlobSources(dgst)
+	if err != nil {
+		return err
+	}
+	src := blobSource{Registry: registryName, Repository: repository}
+	updated := []blobSource{src}
+	for _, s := range sources {
+		if s != src && len(updated) < maxBlobSources {
+			updated = append(updated, s)
+		}
+	}
+
+	buf, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	rootUID, rootGID, err := idtools.GetRootUIDGID(graph.uidMaps, graph.gidMaps)
+	if err != nil {
+		return err
+	}
+	if err := idtools.MkdirAllAs(filepath.Join(graph.root, blobSourcesDir), 0700, rootUID, rootGID); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(graph.blobSourcesPath(dgst), buf, 0600)
+}
+
+// GetBlobSources returns the repositories of the given registry the blob
+// dgst was last pushed to or pulled from, most recent first.
+func (graph *Graph) GetBlobSources(dgst digest.Digest, registryName string) ([]string, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	graph.blobSourcesMutex.Lock(dgst.String())
+	defer graph.blobSourcesMutex.Unlock(dgst.String())
+
+	sources, err := graph.getBlobSources(dgst)
+	if err != nil {
+		return nil, err
+	}
+	var repositories []string
+	for _, s := range sources {
+		if s.Registry == registryName {
+			repositories = append(repositories, s.Repository)
+		}
+	}
+	return repositories, nil
+}
+
+func (graph *Graph) getBlobSources(dgst digest.Digest) ([]blobSource, error) {
+	buf, err := ioutil.ReadFile(graph.blobSourcesPath(dgst))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sources []blobSource
+	if err := json.Unmarshal(buf, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func (graph *Graph) blobSourcesPath(dgst digest.Digest) string {
+	return filepath.Join(graph.root, blobSourcesDir, string(dgst.Algorithm())+"-"+dgst.Hex())
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/sara-nl/docker-1.9.1/pkg/httputils"
+	"github.com/sara-nl/docker-1.9.1/pkg/idtools"
+)
+
+// maxDownloadFailures is the number of times a layer download is retried
+// when a request to the registry fails.
+const maxDownloadFailures = 5
+
+// partialBlobsDir is the directory in the graph root holding the blobs
+// being downloaded. A blob stays there until its layer is registered, so
+// that a pull that failed resumes where it stopped.
+const partialBlobsDir = "_downloads"
+
+// maxPartialBlobAge is how long a blob that is no longer written to is kept
+// in partialBlobsDir. Older blobs belong to pulls that were abandoned, and
+// are removed when the daemon starts.
+const maxPartialBlobAge = 24 * time.Hour
+
+// downloadManager limits the number of layers the daemon downloads at the
+// same time, across all pulls.
+type downloadManager struct {
+	slots chan struct{}
+}
+
+// newDownloadManager returns a download manager allowing up to
+// maxConcurrent downloads at a time. A limit of zero or less means
+// downloads are not limited.
+func newDownloadManager(maxConcurrent int) *downloadManager {
+	m := &downloadManager{}
+	if maxConcurrent > 0 {
+		m.slots = make(chan struct{}, maxConcurrent)
+	}
+	return m
+}
+
+// tryAcquire takes a download slot if one is available, and returns
+// whether it did.
+func (m *downloadManager) tryAcquire() bool {
+	if m.slots == nil {
+		return true
+	}
+	select {
+	case m.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire waits for a download slot and takes it.
+func (m *downloadManager) acquire() {
+	if m.slots != nil {
+		m.slots <- struct{}{}
+	}
+}
+
+// release gives back a slot taken by tryAcquire or acquire.
+func (m *downloadManager) release() {
+	if m.slots != nil {
+		<-m.slots
+	}
+}
+
+// openPartialBlob opens the file holding the part of the blob dgst
+// downloaded so far, creating it if needed.
+func (graph *Graph) openPartialBlob(dgst digest.Digest) (*os.File, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(graph.root, partialBlobsDir)
+	rootUID, rootGID, err := idtools.GetRootUIDGID(graph.uidMaps, graph.gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	if err := idtools.MkdirAllAs(dir, 0700, rootUID, rootGID); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, string(dgst.Algorithm())+"-"+dgst.Hex()), os.O_RDWR|os.O_CREATE, 0600)
+}
+
+// removeStalePartialBlobs removes the blobs of partialBlobsDir that were
+// last written to more than maxPartialBlobAge ago. It must only be called
+// when no pull is running.
+func (graph *Graph) removeStalePartialBlobs() error {
+	dir := filepath.Join(graph.root, partialBlobsDir)
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, fi := range fis {
+		if time.Since(fi.ModTime()) < maxPartialBlobAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, fi.Name())); err != nil {
+			return err
+		}
+		logrus.Debugf("Removed stale partial download %s", fi.Name())
+	}
+	return nil
+}
+
+// openBlob returns a reader for the blob dgst of size bytes of the
+// repository, starting at offset. It also returns the offset the reader
+// actually starts at, which is 0 if the registry doesn't support Range
+// requests. If the connection to the registry breaks, the reader
+// transparently resumes the transfer from the last byte received.
+func (p *v2Puller) openBlob(dgst digest.Digest, offset, size int64) (io.ReadCloser, int64, error) {
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL)
+	if err != nil {
+		return nil, 0, err
+	}
+	blobURL, err := ub.BuildBlobURL(p.repo.Name(), dgst)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequest("GET", blobURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	client := &http.Client{Transport: p.transport}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case offset > 0 && res.StatusCode == http.StatusPartialContent:
+	case res.StatusCode == http.StatusOK:
+		// The registry sends the whole blob.
+		offset = 0
+	default:
+		res.Body.Close()
+		return nil, 0, fmt.Errorf("unexpected status %s fetching blob %s", res.Status, dgst)
+	}
+	if (res.StatusCode != http.StatusPartialContent && res.Header.Get("Accept-Ranges") != "bytes") || size <= 0 {
+		return res.Body, offset, nil
+	}
+	return httputils.ResumableRequestReaderWithInitialResponseAt(client, req, maxDownloadFailures, size, offset, res), offset, nil
+}
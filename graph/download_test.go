@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/client"
+	"github.com/sara-nl/docker-1.9.1/registry"
+	"golang.org/x/net/context"
+)
+
+func TestDownloadManager(t *testing.T) {
+	m := newDownloadManager(2)
+	if !m.tryAcquire() || !m.tryAcquire() {
+		t.Fatal("Expected two download slots to be available")
+	}
+	if m.tryAcquire() {
+		t.Fatal("Expected no more than two downloads at a time")
+	}
+	m.release()
+	if !m.tryAcquire() {
+		t.Fatal("Expected a released download slot to be available")
+	}
+
+	m = newDownloadManager(0)
+	for i := 0; i < 10; i++ {
+		if !m.tryAcquire() {
+			t.Fatal("Expected downloads not to be limited")
+		}
+	}
+}
+
+func TestOpenBlobResume(t *testing.T) {
+	blob := bytes.Repeat([]byte("layer data "), 1000)
+	dgst, err := digest.FromBytes(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/v2/foo/bar/blobs/%s", dgst) {
+			http.NotFound(w, r)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("Accept-Ranges", "bytes")
+		if len(ranges) == 1 {
+			// Announce the whole blob, but drop the connection half
+			// way through.
+			w.Header().Set("Content-Length", strconv.Itoa(len(blob)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(blob[:len(blob)/2])
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		var start int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(blob)-1, len(blob)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(blob[start:])
+	}))
+	defer server.Close()
+
+	repo, err := client.NewRepository(context.Background(), "foo/bar", server.URL, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &v2Puller{
+		endpoint:  registry.APIEndpoint{URL: server.URL},
+		repo:      repo,
+		transport: http.DefaultTransport,
+	}
+
+	rc, _, err := p.openBlob(dgst, 0, int64(len(blob)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, blob) {
+		t.Fatalf("Expected %d bytes of blob data, got %d", len(blob), len(data))
+	}
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[1], fmt.Sprintf("bytes=%d-", len(blob)/2)) {
+		t.Fatalf("Expected the download to resume with a range request, got %q", ranges)
+	}
+}
+
+func TestPartialBlobResume(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+
+	blob := bytes.Repeat([]byte("layer data "), 1000)
+	dgst, err := digest.FromBytes(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	supportsRanges := true
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		var start int
+		if supportsRanges {
+			fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+		}
+		if start > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(blob)-1, len(blob)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write(blob[start:])
+	}))
+	defer server.Close()
+
+	repo, err := client.NewRepository(context.Background(), "foo/bar", server.URL, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &v2Puller{
+		endpoint:  registry.APIEndpoint{URL: server.URL},
+		repo:      repo,
+		transport: http.DefaultTransport,
+	}
+
+	// An earlier pull downloaded half of the blob.
+	f, err := graph.openPartialBlob(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(blob[:len(blob)/2]); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if f, err = graph.openPartialBlob(dgst); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, os.SEEK_END)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, start, err := p.openBlob(dgst, offset, int64(len(blob)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != int64(len(blob)/2) || !bytes.Equal(rest, blob[len(blob)/2:]) {
+		t.Fatalf("Expected the download to resume at %d, got %d bytes from %d", len(blob)/2, len(rest), start)
+	}
+	if ranges[0] != fmt.Sprintf("bytes=%d-", len(blob)/2) {
+		t.Fatalf("Expected a range request, got %q", ranges[0])
+	}
+
+	// Registries that don't support ranges send the whole blob.
+	supportsRanges = false
+	if rc, start, err = p.openBlob(dgst, offset, int64(len(blob))); err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if start != 0 {
+		t.Fatalf("Expected the download to start over, got offset %d", start)
+	}
+
+	if _, err := graph.openPartialBlob("sha256:../../etc"); err == nil {
+		t.Fatal("Expected an error for an invalid digest")
+	}
+}
+
+func TestRemoveStalePartialBlobs(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+
+	// No pull ever ran.
+	if err := graph.removeStalePartialBlobs(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, data := range []string{"stale", "recent"} {
+		dgst, err := digest.FromBytes([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := graph.openPartialBlob(dgst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name())
+		f.Close()
+	}
+	old := time.Now().Add(-maxPartialBlobAge - time.Hour)
+	if err := os.Chtimes(names[0], old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stale blob is removed when the graph is opened again.
+	if _, err := NewGraph(graph.root, graph.driver, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(names[0]); !os.IsNotExist(err) {
+		t.Fatalf("Expected %s to be removed, got %v", filepath.Base(names[0]), err)
+	}
+	if _, err := os.Stat(names[1]); err != nil {
+		t.Fatalf("Expected %s to be kept, got %v", filepath.Base(names[1]), err)
+	}
+}
@@ -11,17 +11,61 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution/digest"
+	"github.com/sara-nl/docker-1.9.1/image"
 	"github.com/sara-nl/docker-1.9.1/pkg/archive"
 	"github.com/sara-nl/docker-1.9.1/pkg/parsers"
 	"github.com/sara-nl/docker-1.9.1/registry"
 )
 
+// ImageExportConfig holds the options of an image export.
+type ImageExportConfig struct {
+	// Names is the set of images, repositories and tags to export.
+	Names []string
+	// ExcludeBase is an image whose layers are left out of the export, for
+	// an archive that can only be loaded where the image is present.
+	ExcludeBase string
+	// Format is the format of the archive, ExportFormatLegacy if empty.
+	Format string
+	// Sign signs the manifests of a bundle with the key of the daemon.
+	Sign bool
+	// Outstream is the writer the archive is written to.
+	Outstream io.Writer
+}
+
 // ImageExport exports list of images to a output stream specified in the
 // config. The exported images are archived into a tar when written to the
 // output stream. All images with the given tag and all versions containing the
-// same tag are exported. names is the set of tags to export, and outStream
-// is the writer which the images are written to.
-func (s *TagStore) ImageExport(names []string, outStream io.Writer) error {
+// same tag are exported. Layers that are part of the base image of the
+// config are not exported.
+func (s *TagStore) ImageExport(imageExportConfig *ImageExportConfig) error {
+	switch imageExportConfig.Format {
+	case "", ExportFormatLegacy:
+		if imageExportConfig.Sign {
+			return fmt.Errorf("only archives in the %s format can be signed", ExportFormatBundle)
+		}
+	case ExportFormatBundle:
+	default:
+		return fmt.Errorf("unknown archive format %q, expected %s or %s", imageExportConfig.Format, ExportFormatLegacy, ExportFormatBundle)
+	}
+
+	exclude := make(map[string]bool)
+	if imageExportConfig.ExcludeBase != "" {
+		base, err := s.LookupImage(imageExportConfig.ExcludeBase)
+		if err != nil {
+			return err
+		}
+		if err := s.graph.WalkHistory(base, func(img image.Image) error {
+			exclude[img.ID] = true
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if imageExportConfig.Format == ExportFormatBundle {
+		return s.exportBundle(imageExportConfig, exclude)
+	}
+
 	// get image json
 	tempdir, err := ioutil.TempDir("", "docker-export-")
 	if err != nil {
@@ -38,7 +82,7 @@ func (s *TagStore) ImageExport(names []string, outStream io.Writer) error {
 			repo[tag] = id
 		}
 	}
-	for _, name := range names {
+	for _, name := range imageExportConfig.Names {
 		name = registry.NormalizeLocalName(name)
 		logrus.Debugf("Serializing %s", name)
 		rootRepo := s.Repositories[name]
@@ -46,7 +90,7 @@ func (s *TagStore) ImageExport(names []string, outStream io.Writer) error {
 			// this is a base repo name, like 'busybox'
 			for tag, id := range rootRepo {
 				addKey(name, tag, id)
-				if err := s.exportImage(id, tempdir); err != nil {
+				if err := s.exportImage(id, tempdir, exclude); err != nil {
 					return err
 				}
 			}
@@ -70,13 +114,13 @@ func (s *TagStore) ImageExport(names []string, outStream io.Writer) error {
 				if len(repoTag) > 0 {
 					addKey(repoName, repoTag, img.ID)
 				}
-				if err := s.exportImage(img.ID, tempdir); err != nil {
+				if err := s.exportImage(img.ID, tempdir, exclude); err != nil {
 					return err
 				}
 
 			} else {
 				// this must be an ID that didn't get looked up just right?
-				if err := s.exportImage(name, tempdir); err != nil {
+				if err := s.exportImage(name, tempdir, exclude); err != nil {
 					return err
 				}
 			}
@@ -109,19 +153,24 @@ func (s *TagStore) ImageExport(names []string, outStream io.Writer) error {
 	}
 	defer fs.Close()
 
-	if _, err := io.Copy(outStream, fs); err != nil {
+	if _, err := io.Copy(imageExportConfig.Outstream, fs); err != nil {
 		return err
 	}
 	logrus.Debugf("End export image")
 	return nil
 }
 
-func (s *TagStore) exportImage(name, tempdir string) error {
+// exportImage exports the image name and its parents to tempdir, stopping
+// at the first image in exclude.
+func (s *TagStore) exportImage(name, tempdir string, exclude map[string]bool) error {
 	for n := name; n != ""; {
 		img, err := s.LookupImage(n)
 		if err != nil || img == nil {
 			return fmt.Errorf("No such image %s", n)
 		}
+		if exclude[img.ID] {
+			return nil
+		}
 
 		// temporary directory
 		tmpImageDir := filepath.Join(tempdir, n)
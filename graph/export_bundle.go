@@ -0,0 +1,256 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+	"github.com/sara-nl/docker-1.9.1/pkg/parsers"
+	"github.com/sara-nl/docker-1.9.1/registry"
+	"github.com/sara-nl/docker-1.9.1/utils"
+)
+
+// Formats of image archives.
+const (
+	// ExportFormatLegacy is the format of archives with a directory per
+	// layer, named after the ID of the layer.
+	ExportFormatLegacy = "legacy"
+	// ExportFormatBundle is the format of archives with v2 image manifests
+	// and content-addressed layer blobs.
+	ExportFormatBundle = "bundle"
+)
+
+// bundleIndexFileName is the file listing the manifests of an image bundle.
+const bundleIndexFileName = "index.json"
+
+// bundleIndex lists the manifests of an image bundle. A bundle is a tar
+// archive holding:
+//
+//	index.json           the bundleIndex
+//	manifests/<hex>      schema 1 manifests, named after their digest
+//	blobs/sha256/<hex>   layer tars, named after their digest
+type bundleIndex struct {
+	SchemaVersion int
+	Manifests     []bundleManifest
+}
+
+// bundleManifest refers to a manifest of an image bundle, and to the name it
+// was exported under, if any.
+type bundleManifest struct {
+	Name   string `json:",omitempty"`
+	Tag    string `json:",omitempty"`
+	Digest digest.Digest
+}
+
+// exportBundle writes the images of the config to its output stream as an
+// image bundle.
+func (s *TagStore) exportBundle(imageExportConfig *ImageExportConfig, exclude map[string]bool) error {
+	tempdir, err := ioutil.TempDir("", "docker-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempdir)
+
+	for _, dir := range []string{"manifests", filepath.Join("blobs", string(digest.Canonical))} {
+		if err := os.MkdirAll(filepath.Join(tempdir, dir), 0755); err != nil {
+			return err
+		}
+	}
+
+	refs, err := s.bundleRefs(imageExportConfig.Names)
+	if err != nil {
+		return err
+	}
+
+	index := bundleIndex{SchemaVersion: 1}
+	blobs := make(map[string]digest.Digest)
+	for _, ref := range refs {
+		dgst, err := s.exportBundleManifest(tempdir, ref, imageExportConfig.Sign, exclude, blobs)
+		if err != nil {
+			return err
+		}
+		index.Manifests = append(index.Manifests, bundleManifest{Name: ref.Name, Tag: ref.Tag, Digest: dgst})
+	}
+
+	indexJSON, err := json.MarshalIndent(index, "", "   ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tempdir, bundleIndexFileName), indexJSON, 0644); err != nil {
+		return err
+	}
+
+	fs, err := archive.Tar(tempdir, archive.Uncompressed)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	_, err = io.Copy(imageExportConfig.Outstream, fs)
+	return err
+}
+
+// bundleRef is an image to export to a bundle, along with the name it is
+// exported under.
+type bundleRef struct {
+	Name string
+	Tag  string
+	ID   string
+}
+
+// bundleRefs resolves the names of images, repositories and tags to export
+// to the images they refer to.
+func (s *TagStore) bundleRefs(names []string) ([]bundleRef, error) {
+	var refs []bundleRef
+	for _, name := range names {
+		name = registry.NormalizeLocalName(name)
+		if repo := s.Repositories[name]; repo != nil {
+			for tag, id := range repo {
+				if utils.DigestReference(tag) {
+					continue
+				}
+				refs = append(refs, bundleRef{Name: name, Tag: tag, ID: id})
+			}
+			continue
+		}
+
+		img, err := s.LookupImage(name)
+		if err != nil {
+			return nil, err
+		}
+		ref := bundleRef{ID: img.ID}
+		repoName, repoTag := parsers.ParseRepositoryTag(name)
+		// Skip digests and IDs, which are not tags.
+		if _, err := digest.ParseDigest(repoTag); err != nil && repoTag != "" {
+			ref.Name, ref.Tag = repoName, repoTag
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// exportBundleManifest writes the manifest of the image of ref, and the
+// blobs of its layers that are not excluded, to the bundle in dir, and
+// returns the digest of the manifest. blobs maps the IDs of the layers
+// already written to the digests of their blobs.
+func (s *TagStore) exportBundleManifest(dir string, ref bundleRef, sign bool, exclude map[string]bool, blobs map[string]digest.Digest) (digest.Digest, error) {
+	img, err := s.graph.Get(ref.ID)
+	if err != nil {
+		return "", err
+	}
+
+	m := &manifest.Manifest{
+		Versioned: manifest.Versioned{
+			SchemaVersion: 1,
+		},
+		Name:         ref.Name,
+		Tag:          ref.Tag,
+		Architecture: img.Architecture,
+		FSLayers:     []manifest.FSLayer{},
+		History:      []manifest.History{},
+	}
+
+	for layer := img; layer != nil; layer, err = s.graph.GetParent(layer) {
+		if err != nil {
+			return "", err
+		}
+
+		dgst, ok := blobs[layer.ID]
+		if !ok {
+			if exclude[layer.ID] {
+				// The blob is not part of the bundle, the manifest
+				// only has to identify the layer.
+				dgst, err = s.graph.legacyLayerDigest(layer)
+			} else {
+				dgst, err = s.exportBlob(dir, layer)
+			}
+			if err != nil {
+				return "", err
+			}
+			blobs[layer.ID] = dgst
+		}
+
+		v1Compatibility, err := s.graph.GenerateV1CompatibilityChain(layer.ID)
+		if err != nil {
+			return "", err
+		}
+
+		m.FSLayers = append(m.FSLayers, manifest.FSLayer{BlobSum: dgst})
+		m.History = append(m.History, manifest.History{V1Compatibility: string(v1Compatibility)})
+	}
+
+	if err := fixHistory(m); err != nil {
+		return "", err
+	}
+
+	var manifestJSON, payload []byte
+	if sign {
+		signed, err := manifest.Sign(m, s.trustKey)
+		if err != nil {
+			return "", err
+		}
+		if manifestJSON, err = signed.MarshalJSON(); err != nil {
+			return "", err
+		}
+		if payload, err = signed.Payload(); err != nil {
+			return "", err
+		}
+	} else {
+		if manifestJSON, err = json.MarshalIndent(m, "", "   "); err != nil {
+			return "", err
+		}
+		payload = manifestJSON
+	}
+
+	dgst, err := digest.FromBytes(payload)
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "manifests", dgst.Hex()), manifestJSON, 0644); err != nil {
+		return "", err
+	}
+	logrus.Debugf("Exported manifest %s for image %s", dgst, img.ID)
+	return dgst, nil
+}
+
+// exportBlob writes the layer of img to the blobs of the bundle in dir, and
+// returns its digest.
+func (s *TagStore) exportBlob(dir string, img *image.Image) (digest.Digest, error) {
+	f, err := ioutil.TempFile(filepath.Join(dir, "blobs"), "layer-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	arch, err := s.graph.TarLayer(img)
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	defer arch.Close()
+
+	digester := digest.Canonical.New()
+	if _, err := io.Copy(io.MultiWriter(f, digester.Hash()), arch); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	dgst := digester.Digest()
+	if err := os.Rename(f.Name(), blobPath(dir, dgst)); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("unable to store blob %s: %v", dgst, err)
+	}
+	return dgst, nil
+}
+
+// blobPath returns the path of the blob dgst in the bundle in dir.
+func blobPath(dir string, dgst digest.Digest) string {
+	return filepath.Join(dir, "blobs", string(dgst.Algorithm()), dgst.Hex())
+}
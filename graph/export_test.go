@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/sara-nl/docker-1.9.1/daemon/events"
+)
+
+func TestImageExportExcludeBase(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	store, err := NewTagStore(path.Join(graph.root, "tags"), &TagStoreConfig{Graph: graph, Events: events.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := registerLayer(graph, "", []string{"base"}, nil, t)
+	app := registerLayer(graph, base.ID, []string{"app"}, nil, t)
+	if err := store.Tag("base", "", base.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag("app", "", app.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	exported := func(config *ImageExportConfig) map[string]bool {
+		buf := new(bytes.Buffer)
+		config.Outstream = buf
+		if err := store.ImageExport(config); err != nil {
+			t.Fatal(err)
+		}
+		dirs := make(map[string]bool)
+		tr := tar.NewReader(buf)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			dirs[strings.Split(hdr.Name, "/")[0]] = true
+		}
+		return dirs
+	}
+
+	dirs := exported(&ImageExportConfig{Names: []string{"app"}})
+	if !dirs[app.ID] || !dirs[base.ID] {
+		t.Fatalf("Expected both layers to be exported, got %v", dirs)
+	}
+	dirs = exported(&ImageExportConfig{Names: []string{"app"}, ExcludeBase: "base"})
+	if !dirs[app.ID] || dirs[base.ID] {
+		t.Fatalf("Expected only the layer on top of the base image to be exported, got %v", dirs)
+	}
+}
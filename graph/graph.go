@@ -101,6 +101,8 @@ type Graph struct {
 	driver           graphdriver.Driver
 	imagesMutex      sync.Mutex
 	imageMutex       imageMutex // protect images in driver.
+	blobMutex        imageMutex // serializes downloads of each blob.
+	blobSourcesMutex imageMutex // protects the sources of each blob.
 	retained         *retainedLayers
 	tarSplitDisabled bool
 	uidMaps          []idtools.IDMap
@@ -109,6 +111,20 @@ type Graph struct {
 	// access to parentRefs must be protected with imageMutex locking the image id
 	// on the key of the map (e.g. imageMutex.Lock(img.ID), parentRefs[img.ID]...)
 	parentRefs map[string]int
+
+	// layers maps chain IDs to the driver layer storing them and layerRefs
+	// counts the images sharing each driver layer. Both are protected by
+	// layersMutex.
+	layersMutex sync.Mutex
+	layers      map[string]string
+	layerRefs   map[string]int
+
+	// aliases maps the IDs images were known by before they got their
+	// content-addressable ID to that ID. aliasIndex resolves prefixes of
+	// the aliases.
+	aliasesMutex sync.RWMutex
+	aliases      map[string]string
+	aliasIndex   *truncindex.TruncIndex
 }
 
 // file names for ./graph/<ID>/
@@ -119,8 +135,18 @@ const (
 	tarDataFileName         = "tar-data.json.gz"
 	v1CompatibilityFileName = "v1Compatibility"
 	parentFileName          = "parent"
+	lastUsedFileName        = "lastused"
+	lastPulledFileName      = "lastpulled"
+	addedFileName           = "added"
+	useCountFileName        = "usecount"
+	chainIDFileName         = "chainid"
+	layerIDFileName         = "layer"
 )
 
+// aliasesFileName is the file in the graph root holding the aliases of
+// images.
+const aliasesFileName = "aliases.json"
+
 var (
 	// ErrDigestNotSet is used when request the digest for a layer
 	// but the layer has no digest value or content to compute the
@@ -153,6 +179,10 @@ func NewGraph(root string, driver graphdriver.Driver, uidMaps, gidMaps []idtools
 		uidMaps:    uidMaps,
 		gidMaps:    gidMaps,
 		parentRefs: make(map[string]int),
+		layers:     make(map[string]string),
+		layerRefs:  make(map[string]int),
+		aliases:    make(map[string]string),
+		aliasIndex: truncindex.NewTruncIndex([]string{}),
 	}
 
 	// Windows does not currently support tarsplit functionality.
@@ -160,15 +190,36 @@ func NewGraph(root string, driver graphdriver.Driver, uidMaps, gidMaps []idtools
 		graph.tarSplitDisabled = true
 	}
 
+	if err := graph.loadAliases(); err != nil {
+		return nil, err
+	}
+	if err := graph.migrate(); err != nil {
+		return nil, fmt.Errorf("Error migrating images to content-addressable IDs: %v", err)
+	}
 	if err := graph.restore(); err != nil {
 		return nil, err
 	}
+	if err := graph.removeStalePartialBlobs(); err != nil {
+		logrus.Warnf("Failed to remove stale partial downloads: %v", err)
+	}
 	return graph, nil
 }
 
 // IsHeld returns whether the given layerID is being used by an ongoing pull or build.
+// Layers retained under the ID they were pulled with count for the image
+// registered under an alias of it.
 func (graph *Graph) IsHeld(layerID string) bool {
-	return graph.retained.Exists(layerID)
+	if graph.retained.Exists(layerID) {
+		return true
+	}
+	graph.aliasesMutex.RLock()
+	defer graph.aliasesMutex.RUnlock()
+	for alias, id := range graph.aliases {
+		if id == layerID && graph.retained.Exists(alias) {
+			return true
+		}
+	}
+	return false
 }
 
 func (graph *Graph) restore() error {
@@ -179,7 +230,11 @@ func (graph *Graph) restore() error {
 	var ids = []string{}
 	for _, v := range dir {
 		id := v.Name()
-		if graph.driver.Exists(id) {
+		if image.ValidateID(id) != nil {
+			continue
+		}
+		layer := graph.LayerID(id)
+		if graph.driver.Exists(layer) {
 			img, err := graph.loadImage(id)
 			if err != nil {
 				logrus.Warnf("ignoring image %s, it could not be restored: %v", id, err)
@@ -188,6 +243,7 @@ func (graph *Graph) restore() error {
 			graph.imageMutex.Lock(img.Parent)
 			graph.parentRefs[img.Parent]++
 			graph.imageMutex.Unlock(img.Parent)
+			graph.addLayerRef(id, layer)
 			ids = append(ids, id)
 		}
 	}
@@ -216,7 +272,7 @@ func (graph *Graph) Exists(id string) bool {
 
 // Get returns the image with the given id, or an error if the image doesn't exist.
 func (graph *Graph) Get(name string) (*image.Image, error) {
-	id, err := graph.idIndex.Get(name)
+	id, err := graph.resolveID(name)
 	if err != nil {
 		return nil, fmt.Errorf("could not find image: %v", err)
 	}
@@ -229,7 +285,7 @@ func (graph *Graph) Get(name string) (*image.Image, error) {
 	}
 
 	if img.Size < 0 {
-		size, err := graph.driver.DiffSize(img.ID, img.Parent)
+		size, err := graph.driver.DiffSize(graph.LayerID(img.ID), graph.LayerID(img.Parent))
 		if err != nil {
 			return nil, fmt.Errorf("unable to calculate size of image id %q: %s", img.ID, err)
 		}
@@ -242,10 +298,10 @@ func (graph *Graph) Get(name string) (*image.Image, error) {
 	return img, nil
 }
 
-// Create creates a new image and registers it in the graph.
+// Create creates a new image and registers it in the graph. The ID of the
+// image is derived from its configuration and layer data.
 func (graph *Graph) Create(layerData io.Reader, containerID, containerImage, comment, author string, containerConfig, config *runconfig.Config) (*image.Image, error) {
 	img := &image.Image{
-		ID:            stringid.GenerateRandomID(),
 		Comment:       comment,
 		Created:       time.Now().UTC(),
 		DockerVersion: dockerversion.VERSION,
@@ -261,14 +317,22 @@ func (graph *Graph) Create(layerData io.Reader, containerID, containerImage, com
 		img.ContainerConfig = *containerConfig
 	}
 
-	if err := graph.Register(v1Descriptor{img}, layerData); err != nil {
+	graph.imagesMutex.Lock()
+	id, err := graph.register(v1Descriptor{img}, layerData)
+	graph.imagesMutex.Unlock()
+	if err != nil {
 		return nil, err
 	}
-	return img, nil
+	return graph.Get(id)
 }
 
 // Register imports a pre-existing image into the graph.
 // Returns nil if the image is already registered.
+//
+// Images described by a content-addressable descriptor are registered under
+// the ID of the descriptor. Any other image is registered under an ID
+// derived from its configuration and layer data, and the ID of the
+// descriptor becomes an alias of it.
 func (graph *Graph) Register(im image.Descriptor, layerData io.Reader) (err error) {
 	imgID := im.ID()
 
@@ -285,73 +349,144 @@ func (graph *Graph) Register(im image.Descriptor, layerData io.Reader) (err erro
 	graph.imageMutex.Lock(imgID)
 	defer graph.imageMutex.Unlock(imgID)
 
-	return graph.register(im, layerData)
+	_, err = graph.register(im, layerData)
+	return err
 }
 
-func (graph *Graph) register(im image.Descriptor, layerData io.Reader) (err error) {
-	imgID := im.ID()
+// register stores the image and returns the ID it is registered under. The
+// caller must hold imagesMutex.
+func (graph *Graph) register(im image.Descriptor, layerData io.Reader) (id string, err error) {
+	givenID := im.ID()
 
 	// Skip register if image is already registered
-	if graph.Exists(imgID) {
-		return nil
+	if givenID != "" {
+		if img, err := graph.Get(givenID); err == nil {
+			return img.ID, nil
+		}
 	}
 
+	parent := im.Parent()
+	if parent != "" {
+		if parent, err = graph.resolveID(parent); err != nil {
+			return "", fmt.Errorf("could not find parent image %s: %v", im.Parent(), err)
+		}
+	}
+	config, err := im.MarshalConfig()
+	if err != nil {
+		return "", err
+	}
+
+	// The digest of the layer data is needed up front, both for the ID of
+	// the image and to find out whether the layer is already stored.
+	layerDigest, layerData, cleanup, err := graph.digestLayer(im, layerData)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	id = givenID
+	var v1Config []byte
+	if _, ok := im.(contentAddressable); !ok {
+		var parentID digest.Digest
+		if parent != "" {
+			parentID = digest.NewDigestFromHex(string(digest.Canonical), parent)
+		}
+		caConfig, err := image.MakeImageConfig(config, layerDigest, parentID)
+		if err != nil {
+			return "", err
+		}
+		strongID, err := image.StrongID(caConfig)
+		if err != nil {
+			return "", err
+		}
+		if givenID != "" {
+			v1Config = config
+		}
+		config = caConfig
+		id = strongID.Hex()
+
+		if id != givenID {
+			graph.imageMutex.Lock(id)
+			defer graph.imageMutex.Unlock(id)
+		}
+		if graph.Exists(id) {
+			return id, graph.addAlias(givenID, id)
+		}
+	}
+
+	// Reuse the driver layer of an image with the same chain of layers.
+	chain := graph.chainID(parent, layerDigest)
+	layer, layerExists := graph.lookupLayer(chain)
+	if !layerExists {
+		layer = id
+	}
+	parentLayer := graph.LayerID(parent)
+
 	// The returned `error` must be named in this function's signature so that
 	// `err` is not shadowed in this deferred cleanup.
 	defer func() {
 		// If any error occurs, remove the new dir from the driver.
 		// Don't check for errors since the dir might not have been created.
-		if err != nil {
-			graph.driver.Remove(imgID)
+		if err != nil && !layerExists {
+			graph.driver.Remove(layer)
 		}
 	}()
 
 	// Ensure that the image root does not exist on the filesystem
 	// when it is not registered in the graph.
 	// This is common when you switch from one graph driver to another
-	if err := os.RemoveAll(graph.imageRoot(imgID)); err != nil && !os.IsNotExist(err) {
-		return err
+	if err := os.RemoveAll(graph.imageRoot(id)); err != nil && !os.IsNotExist(err) {
+		return "", err
 	}
 
-	// If the driver has this ID but the graph doesn't, remove it from the driver to start fresh.
-	// (the graph is the source of truth).
-	// Ignore errors, since we don't know if the driver correctly returns ErrNotExist.
-	// (FIXME: make that mandatory for drivers).
-	graph.driver.Remove(imgID)
-
 	tmp, err := graph.mktemp()
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer os.RemoveAll(tmp)
 
-	parent := im.Parent()
+	if !layerExists {
+		// If the driver has this ID but the graph doesn't, remove it from the driver to start fresh.
+		// (the graph is the source of truth).
+		// Ignore errors, since we don't know if the driver correctly returns ErrNotExist.
+		// (FIXME: make that mandatory for drivers).
+		graph.driver.Remove(layer)
 
-	// Create root filesystem in the driver
-	if err := createRootFilesystemInDriver(graph, imgID, parent, layerData); err != nil {
-		return err
+		// Create root filesystem in the driver
+		if err := createRootFilesystemInDriver(graph, layer, parentLayer, layerData); err != nil {
+			return "", err
+		}
 	}
 
 	// Apply the diff/layer
-	config, err := im.MarshalConfig()
-	if err != nil {
-		return err
+	if err := graph.storeImage(id, parent, layer, parentLayer, !layerExists, config, layerData, tmp); err != nil {
+		return "", err
 	}
-	if err := graph.storeImage(imgID, parent, config, layerData, tmp); err != nil {
-		return err
+	if err := graph.storeLayerRefs(tmp, id, layer, chain); err != nil {
+		return "", err
+	}
+	if v1Config != nil {
+		if err := ioutil.WriteFile(filepath.Join(tmp, v1CompatibilityFileName), v1Config, 0600); err != nil {
+			return "", err
+		}
+	}
+	if err := writeTimeFile(tmp, addedFileName, time.Now()); err != nil {
+		return "", err
 	}
 	// Commit
-	if err := os.Rename(tmp, graph.imageRoot(imgID)); err != nil {
-		return err
+	if err := os.Rename(tmp, graph.imageRoot(id)); err != nil {
+		return "", err
 	}
 
-	graph.idIndex.Add(imgID)
+	graph.idIndex.Add(id)
 
 	graph.imageMutex.Lock(parent)
 	graph.parentRefs[parent]++
 	graph.imageMutex.Unlock(parent)
 
-	return nil
+	graph.addLayerRef(id, layer)
+
+	return id, graph.addAlias(givenID, id)
 }
 
 func createRootFilesystemInDriver(graph *Graph, id, parent string, layerData io.Reader) error {
@@ -406,7 +541,7 @@ func (graph *Graph) mktemp() (string, error) {
 
 // Delete atomically removes an image from the graph.
 func (graph *Graph) Delete(name string) error {
-	id, err := graph.idIndex.Get(name)
+	id, err := graph.resolveID(name)
 	if err != nil {
 		return err
 	}
@@ -414,6 +549,11 @@ func (graph *Graph) Delete(name string) error {
 	if err != nil {
 		return err
 	}
+	layer := graph.LayerID(id)
+	chain, err := graph.getChainID(id)
+	if err != nil {
+		return err
+	}
 	graph.idIndex.Delete(id)
 	tmp, err := graph.mktemp()
 	if err != nil {
@@ -425,8 +565,14 @@ func (graph *Graph) Delete(name string) error {
 			tmp = graph.imageRoot(id)
 		}
 	}
-	// Remove rootfs data from the driver
-	graph.driver.Remove(id)
+	// Remove rootfs data from the driver, unless other images still share
+	// the layer.
+	if graph.removeLayerRef(layer, chain) {
+		graph.driver.Remove(layer)
+	}
+	if err := graph.removeAliases(id); err != nil {
+		logrus.Warnf("failed to remove the aliases of image %s: %v", id, err)
+	}
 
 	graph.imageMutex.Lock(img.Parent)
 	graph.parentRefs[img.Parent]--
@@ -519,7 +665,7 @@ func (graph *Graph) TarLayer(img *image.Image) (arch io.ReadCloser, err error) {
 	rdr, err := graph.assembleTarLayer(img)
 	if err != nil {
 		logrus.Debugf("[graph] TarLayer with traditional differ: %s", img.ID)
-		return graph.driver.Diff(img.ID, img.Parent)
+		return graph.driver.Diff(graph.LayerID(img.ID), graph.LayerID(img.Parent))
 	}
 	return rdr, nil
 }
@@ -596,6 +742,7 @@ func (graph *Graph) saveSize(root string, size int64) error {
 
 // SetLayerDigest sets the digest for the image layer to the provided value.
 func (graph *Graph) SetLayerDigest(id string, dgst digest.Digest) error {
+	id = graph.resolveAlias(id)
 	graph.imageMutex.Lock(id)
 	defer graph.imageMutex.Unlock(id)
 
@@ -611,6 +758,7 @@ func (graph *Graph) setLayerDigest(id string, dgst digest.Digest) error {
 
 // GetLayerDigest gets the digest for the provide image layer id.
 func (graph *Graph) GetLayerDigest(id string) (digest.Digest, error) {
+	id = graph.resolveAlias(id)
 	graph.imageMutex.Lock(id)
 	defer graph.imageMutex.Unlock(id)
 
@@ -629,6 +777,133 @@ func (graph *Graph) getLayerDigest(id string) (digest.Digest, error) {
 	return digest.ParseDigest(string(cs))
 }
 
+// ImageUsage holds the usage statistics recorded for an image.
+type ImageUsage struct {
+	// LastUsed is the last time a container was started from the image. It
+	// is zero if the image was never used.
+	LastUsed time.Time
+	// LastPulled is the last time the image was pulled from a registry. It
+	// is zero if the image was never pulled.
+	LastPulled time.Time
+	// UseCount is the number of times a container was started from the
+	// image.
+	UseCount int
+}
+
+// MarkUsed records that the image was used to start a container at t, and
+// increments its use count.
+func (graph *Graph) MarkUsed(id string, t time.Time) error {
+	id = graph.resolveAlias(id)
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	root := graph.imageRoot(id)
+	usage, err := graph.getUsage(id)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, useCountFileName), []byte(strconv.Itoa(usage.UseCount+1)), 0600); err != nil {
+		return fmt.Errorf("Error storing use count in %s/%s: %s", root, useCountFileName, err)
+	}
+	return writeTimeFile(root, lastUsedFileName, t)
+}
+
+// SetLastPulled records t as the last time the image was pulled.
+func (graph *Graph) SetLastPulled(id string, t time.Time) error {
+	id = graph.resolveAlias(id)
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	return writeTimeFile(graph.imageRoot(id), lastPulledFileName, t)
+}
+
+// GetUsage returns the usage statistics recorded for the image.
+func (graph *Graph) GetUsage(id string) (ImageUsage, error) {
+	id = graph.resolveAlias(id)
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	return graph.getUsage(id)
+}
+
+func (graph *Graph) getUsage(id string) (ImageUsage, error) {
+	var (
+		usage ImageUsage
+		err   error
+	)
+	root := graph.imageRoot(id)
+	if usage.LastUsed, err = readTimeFile(root, lastUsedFileName); err != nil {
+		return usage, err
+	}
+	if usage.LastPulled, err = readTimeFile(root, lastPulledFileName); err != nil {
+		return usage, err
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(root, useCountFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return usage, err
+	}
+	if usage.UseCount, err = strconv.Atoi(strings.TrimSpace(string(buf))); err != nil {
+		return usage, fmt.Errorf("Error reading use count from %s/%s: %s", root, useCountFileName, err)
+	}
+	return usage, nil
+}
+
+// LastUsed returns the last time the image was used by a container. Images
+// that were never used report the last time they were pulled or, failing
+// that, the time they were added to the graph. Only images registered
+// before that time was recorded report their creation time.
+func (graph *Graph) LastUsed(id string) (time.Time, error) {
+	id = graph.resolveAlias(id)
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	usage, err := graph.getUsage(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !usage.LastUsed.IsZero() {
+		return usage.LastUsed, nil
+	}
+	if !usage.LastPulled.IsZero() {
+		return usage.LastPulled, nil
+	}
+	added, err := readTimeFile(graph.imageRoot(id), addedFileName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !added.IsZero() {
+		return added, nil
+	}
+	img, err := graph.loadImage(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return img.Created, nil
+}
+
+func writeTimeFile(root, name string, t time.Time) error {
+	if err := ioutil.WriteFile(filepath.Join(root, name), []byte(t.UTC().Format(time.RFC3339Nano)), 0600); err != nil {
+		return fmt.Errorf("Error storing time in %s/%s: %s", root, name, err)
+	}
+	return nil
+}
+
+// readTimeFile reads a time stored by writeTimeFile. A missing file results
+// in the zero time.
+func readTimeFile(root, name string) (time.Time, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(root, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(buf)))
+}
+
 // SetV1CompatibilityConfig stores the v1Compatibility JSON data associated
 // with the image in the manifest to the disk
 func (graph *Graph) SetV1CompatibilityConfig(id string, data []byte) error {
@@ -718,11 +993,18 @@ func jsonPath(root string) string {
 // storeImage stores file system layer data for the given image to the
 // graph's storage driver. Image metadata is stored in a file
 // at the specified root directory.
-func (graph *Graph) storeImage(id, parent string, config []byte, layerData io.Reader, root string) (err error) {
+// If apply is false, the layer already exists in the driver and layerData
+// is only used to record the tar-split metadata.
+func (graph *Graph) storeImage(id, parent, layer, parentLayer string, apply bool, config []byte, layerData io.Reader, root string) (err error) {
 	var size int64
 	// Store the layer. If layerData is not nil, unpack it into the new layer
 	if layerData != nil {
-		if size, err = graph.disassembleAndApplyTarLayer(id, parent, layerData, root); err != nil {
+		if size, err = graph.disassembleAndApplyTarLayer(layer, parentLayer, apply, layerData, root); err != nil {
+			return err
+		}
+	}
+	if !apply {
+		if size, err = graph.driver.DiffSize(layer, parentLayer); err != nil {
 			return err
 		}
 	}
@@ -749,7 +1031,7 @@ func (graph *Graph) storeImage(id, parent string, config []byte, layerData io.Re
 	return nil
 }
 
-func (graph *Graph) disassembleAndApplyTarLayer(id, parent string, layerData io.Reader, root string) (size int64, err error) {
+func (graph *Graph) disassembleAndApplyTarLayer(id, parent string, apply bool, layerData io.Reader, root string) (size int64, err error) {
 	var ar io.Reader
 
 	if graph.tarSplitDisabled {
@@ -781,6 +1063,10 @@ func (graph *Graph) disassembleAndApplyTarLayer(id, parent string, layerData io.
 		ar = archive.Reader(rdr)
 	}
 
+	if !apply {
+		_, err = io.Copy(ioutil.Discard, ar)
+		return 0, err
+	}
 	if size, err = graph.driver.ApplyDiff(id, parent, ar); err != nil {
 		return 0, err
 	}
@@ -814,12 +1100,13 @@ func (graph *Graph) assembleTarLayer(img *image.Image) (io.ReadCloser, error) {
 		defer mfz.Close()
 
 		// get our relative path to the container
-		fsLayer, err := graph.driver.Get(img.ID, "")
+		layer := graph.LayerID(img.ID)
+		fsLayer, err := graph.driver.Get(layer, "")
 		if err != nil {
 			pW.CloseWithError(err)
 			return
 		}
-		defer graph.driver.Put(img.ID)
+		defer graph.driver.Put(layer)
 
 		metaUnpacker := storage.NewJSONUnpacker(mfz)
 		fileGetter := storage.NewPathFileGetter(fsLayer)
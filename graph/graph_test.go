@@ -2,13 +2,17 @@ package graph
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/docker/distribution/digest"
 	"github.com/sara-nl/docker-1.9.1/autogen/dockerversion"
 	"github.com/sara-nl/docker-1.9.1/daemon/graphdriver"
 	"github.com/sara-nl/docker-1.9.1/image"
@@ -42,7 +46,7 @@ func TestMount(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if _, err := driver.Get(image.ID, ""); err != nil {
+	if _, err := driver.Get(graph.LayerID(image.ID), ""); err != nil {
 		t.Fatal(err)
 	}
 
@@ -141,8 +145,9 @@ func TestRegister(t *testing.T) {
 	if resultImg, err := graph.Get(image.ID); err != nil {
 		t.Fatal(err)
 	} else {
-		if resultImg.ID != image.ID {
-			t.Fatalf("Wrong image ID. Should be '%s', not '%s'", image.ID, resultImg.ID)
+		// The image is stored under its content-addressable ID.
+		if images[resultImg.ID] == nil {
+			t.Fatalf("Wrong image ID. Image %s is not stored as '%s'", image.ID, resultImg.ID)
 		}
 		if resultImg.Comment != image.Comment {
 			t.Fatalf("Wrong image comment. Should be '%s', not '%s'", image.Comment, resultImg.Comment)
@@ -260,13 +265,224 @@ func TestByParent(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	parent, err := graph.Get(parentImage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
 	byParent := graph.ByParent()
-	numChildren := len(byParent[parentImage.ID])
+	numChildren := len(byParent[parent.ID])
 	if numChildren != 2 {
 		t.Fatalf("Expected 2 children, found %d", numChildren)
 	}
 }
 
+func TestImageUsage(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	before := time.Now()
+	img := createTestImage(graph, t)
+	after := time.Now()
+
+	usage, err := graph.GetUsage(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !usage.LastUsed.IsZero() || !usage.LastPulled.IsZero() || usage.UseCount != 0 {
+		t.Fatalf("Expected no usage for a new image, got %+v", usage)
+	}
+	lastUsed, err := graph.LastUsed(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastUsed.Before(before) || lastUsed.After(after) {
+		t.Fatalf("Expected an unused image to report the time it was added, between %v and %v, got %v", before, after, lastUsed)
+	}
+
+	pulled := after.Add(time.Minute)
+	if err := graph.SetLastPulled(img.ID, pulled); err != nil {
+		t.Fatal(err)
+	}
+	if lastUsed, err = graph.LastUsed(img.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !lastUsed.Equal(pulled) {
+		t.Fatalf("Expected an unused image to report its pull time %v, got %v", pulled, lastUsed)
+	}
+
+	used := after.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if err := graph.MarkUsed(img.ID, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if usage, err = graph.GetUsage(img.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !usage.LastUsed.Equal(used) || !usage.LastPulled.Equal(pulled) || usage.UseCount != 2 {
+		t.Fatalf("Expected last used %v, last pulled %v and 2 uses, got %+v", used, pulled, usage)
+	}
+	if lastUsed, err = graph.LastUsed(img.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !lastUsed.Equal(used) {
+		t.Fatalf("Expected last used time %v, got %v", used, lastUsed)
+	}
+}
+
+func TestBlobSources(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	dgst := digest.Digest("sha256:" + strings.Repeat("ab", 32))
+
+	sources, err := graph.GetBlobSources(dgst, "registry.corp:5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 0 {
+		t.Fatalf("Expected no sources for a new blob, got %v", sources)
+	}
+
+	for _, repo := range []string{"base", "app", "base"} {
+		if err := graph.AddBlobSource(dgst, "registry.corp:5000", repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := graph.AddBlobSource(dgst, "docker.io", "library/base"); err != nil {
+		t.Fatal(err)
+	}
+	if sources, err = graph.GetBlobSources(dgst, "registry.corp:5000"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sources, []string{"base", "app"}) {
+		t.Fatalf("Expected the sources [base app], most recent first, got %v", sources)
+	}
+
+	for i := 0; i < maxBlobSources+2; i++ {
+		if err := graph.AddBlobSource(dgst, "registry.corp:5000", fmt.Sprintf("repo%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sources, err = graph.GetBlobSources(dgst, "registry.corp:5000"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != maxBlobSources || sources[0] != fmt.Sprintf("repo%d", maxBlobSources+1) {
+		t.Fatalf("Expected the %d most recent sources, got %v", maxBlobSources, sources)
+	}
+
+	if err := graph.AddBlobSource("sha256:../../etc", "registry.corp:5000", "base"); err == nil {
+		t.Fatal("Expected an invalid digest to be rejected")
+	}
+}
+
+func TestRegisterSharesLayers(t *testing.T) {
+	graph, driver := tempGraph(t)
+	defer nukeGraph(graph)
+
+	var ids []string
+	for _, comment := range []string{"first", "second"} {
+		archive, err := fakeTar()
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := &image.Image{
+			ID:      stringid.GenerateNonCryptoID(),
+			Comment: comment,
+			Created: time.Now(),
+		}
+		if err := graph.Register(v1Descriptor{img}, archive); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := graph.Get(img.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, stored.ID)
+	}
+	if ids[0] == ids[1] {
+		t.Fatalf("Expected images with different configurations to have different IDs, both are %s", ids[0])
+	}
+
+	layer := graph.LayerID(ids[0])
+	if graph.LayerID(ids[1]) != layer {
+		t.Fatalf("Expected both images to share driver layer %s, got %s", layer, graph.LayerID(ids[1]))
+	}
+
+	if err := graph.Delete(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !driver.Exists(layer) {
+		t.Fatal("Driver layer removed while still used by an image")
+	}
+	if _, err := graph.Get(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Delete(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if driver.Exists(layer) {
+		t.Fatal("Driver layer not removed with the last image using it")
+	}
+}
+
+func TestRegisterAlias(t *testing.T) {
+	graph, driver := tempGraph(t)
+	defer driver.Cleanup()
+	defer os.RemoveAll(graph.root)
+
+	archive, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := &image.Image{
+		ID:      stringid.GenerateNonCryptoID(),
+		Comment: "testing",
+		Created: time.Now(),
+	}
+	if err := graph.Register(v1Descriptor{img}, archive); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := graph.Get(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ID == img.ID {
+		t.Fatal("Expected the image to be stored under a content-addressable ID")
+	}
+	v1Config, err := graph.getV1CompatibilityConfig(stored.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1Img, err := image.NewImgJSON(v1Config); err != nil || v1Img.ID != img.ID {
+		t.Fatalf("Expected the v1 configuration to keep ID %s, got %s", img.ID, v1Config)
+	}
+
+	// Aliases survive a restart.
+	graph, err = NewGraph(graph.root, driver, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved, err := graph.Get(img.ID); err != nil || resolved.ID != stored.ID {
+		t.Fatalf("Expected alias %s to resolve to %s, got %v (%v)", img.ID, stored.ID, resolved, err)
+	}
+
+	// Registering the same image again is a no-op.
+	archive, err = fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Register(v1Descriptor{img}, archive); err != nil {
+		t.Fatal(err)
+	}
+	assertNImages(graph, t, 1)
+
+	if err := graph.Delete(stored.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := graph.Get(img.ID); err == nil {
+		t.Fatal("Expected the alias to be removed with the image")
+	}
+}
+
 func createTestImage(graph *Graph, t *testing.T) *image.Image {
 	archive, err := fakeTar()
 	if err != nil {
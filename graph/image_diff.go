@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/sara-nl/docker-1.9.1/api/types"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+)
+
+// Changes returns the changes to the filesystem of the image from that
+// result in the filesystem of the image to. If size is true, the change in
+// size of each changed file is computed as well.
+func (graph *Graph) Changes(from, to *image.Image, size bool) ([]types.ImageChange, error) {
+	fromLayer := graph.LayerID(from.ID)
+	toLayer := graph.LayerID(to.ID)
+
+	fromDir, err := graph.driver.Get(fromLayer, "")
+	if err != nil {
+		return nil, err
+	}
+	defer graph.driver.Put(fromLayer)
+	toDir, err := graph.driver.Get(toLayer, "")
+	if err != nil {
+		return nil, err
+	}
+	defer graph.driver.Put(toLayer)
+
+	var changes []archive.Change
+	if to.Parent == from.ID {
+		// The driver knows what changed in a layer.
+		changes, err = graph.driver.Changes(toLayer, fromLayer)
+	} else {
+		changes, err = archive.ChangesDirs(toDir, fromDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	imageChanges := make([]types.ImageChange, 0, len(changes))
+	for _, change := range changes {
+		c := types.ImageChange{
+			Path: change.Path,
+			Kind: int(change.Kind),
+		}
+		if size {
+			c.SizeDelta = fileSize(toDir, change.Path) - fileSize(fromDir, change.Path)
+		}
+		imageChanges = append(imageChanges, c)
+	}
+	return imageChanges, nil
+}
+
+// fileSize returns the size of the regular file at path in the directory
+// dir, or 0 if there is no such file.
+func fileSize(dir, path string) int64 {
+	fi, err := os.Lstat(filepath.Join(dir, path))
+	if err != nil || !fi.Mode().IsRegular() {
+		return 0
+	}
+	return fi.Size()
+}
+
+// ImageDiff returns the changes to the filesystem of the image name that
+// result in the filesystem of the image other.
+func (s *TagStore) ImageDiff(name, other string, size bool) ([]types.ImageChange, error) {
+	from, err := s.LookupImage(name)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.LookupImage(other)
+	if err != nil {
+		return nil, err
+	}
+	return s.graph.Changes(from, to, size)
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/sara-nl/docker-1.9.1/api/types"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+)
+
+func TestChanges(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+
+	base := registerLayer(graph, "", []string{"base", "a"}, nil, t)
+	middle := registerLayer(graph, base.ID, []string{"b"}, []string{"a"}, t)
+	top := registerLayer(graph, middle.ID, []string{"c", "base-longer"}, nil, t)
+
+	changes, err := graph.Changes(base, top, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]types.ImageChange{
+		"/a":           {Path: "/a", Kind: int(archive.ChangeDelete), SizeDelta: -int64(len("a"))},
+		"/b":           {Path: "/b", Kind: int(archive.ChangeAdd), SizeDelta: int64(len("b"))},
+		"/c":           {Path: "/c", Kind: int(archive.ChangeAdd), SizeDelta: int64(len("c"))},
+		"/base-longer": {Path: "/base-longer", Kind: int(archive.ChangeAdd), SizeDelta: int64(len("base-longer"))},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+	}
+	for _, change := range changes {
+		if change != expected[change.Path] {
+			t.Fatalf("Expected change %+v, got %+v", expected[change.Path], change)
+		}
+	}
+
+	// Changes between an image and its parent come from the driver.
+	changes, err = graph.Changes(middle, top, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %v", changes)
+	}
+	for _, change := range changes {
+		if change.Kind != int(archive.ChangeAdd) || change.SizeDelta != 0 {
+			t.Fatalf("Expected an addition without size, got %+v", change)
+		}
+	}
+
+	// The other way around, additions become deletions.
+	changes, err = graph.Changes(top, middle, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if change.Kind != int(archive.ChangeDelete) {
+			t.Fatalf("Expected a deletion, got %+v", change)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/digest"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/truncindex"
+)
+
+// contentAddressable is implemented by image descriptors whose ID is
+// already derived from their content, such as the images of a v2 manifest.
+// The graph registers them under their own ID.
+type contentAddressable interface {
+	// LayerDigest returns the digest of the layer data of the image.
+	LayerDigest() digest.Digest
+}
+
+// digestLayer returns the digest of the layer data of the image described
+// by im, along with a reader for the layer data to use instead of
+// layerData. Unless the descriptor provides the digest, the layer data is
+// spooled to a temporary file to compute it. cleanup must be called once the
+// returned reader is no longer needed.
+func (graph *Graph) digestLayer(im image.Descriptor, layerData io.Reader) (dgst digest.Digest, rdr io.Reader, cleanup func(), err error) {
+	cleanup = func() {}
+	if ca, ok := im.(contentAddressable); ok && ca.LayerDigest() != "" {
+		return ca.LayerDigest(), layerData, cleanup, nil
+	}
+	if layerData == nil {
+		dgst, err := digest.FromBytes(nil)
+		return dgst, nil, cleanup, err
+	}
+
+	tmp, err := graph.mktemp()
+	if err != nil {
+		return "", nil, cleanup, err
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+
+	f, err := os.Create(filepath.Join(tmp, "layer"))
+	if err != nil {
+		cleanup()
+		return "", nil, func() {}, err
+	}
+	cleanup = func() {
+		f.Close()
+		os.RemoveAll(tmp)
+	}
+
+	digester := digest.Canonical.New()
+	if _, err := io.Copy(io.MultiWriter(f, digester.Hash()), layerData); err != nil {
+		cleanup()
+		return "", nil, func() {}, err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		cleanup()
+		return "", nil, func() {}, err
+	}
+	return digester.Digest(), f, cleanup, nil
+}
+
+// chainID returns the ID of the chain of layers formed by the layer with the
+// given digest on top of the layers of the parent image. It is empty if the
+// chain of the parent is unknown.
+func (graph *Graph) chainID(parent string, layerDigest digest.Digest) string {
+	if parent == "" {
+		return layerDigest.String()
+	}
+	parentChain, err := graph.getChainID(parent)
+	if err != nil || parentChain == "" {
+		return ""
+	}
+	chain, err := digest.FromBytes([]byte(parentChain + " " + layerDigest.String()))
+	if err != nil {
+		return ""
+	}
+	return chain.String()
+}
+
+// getChainID returns the chain ID of the layers of the image, or an empty
+// string if it is unknown.
+func (graph *Graph) getChainID(id string) (string, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(graph.imageRoot(id), chainIDFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// LayerID returns the ID of the storage driver layer holding the filesystem
+// of the image with the given ID. Images with the same chain of layers share
+// a driver layer, so it is not necessarily the ID of the image itself.
+func (graph *Graph) LayerID(id string) string {
+	if id == "" {
+		return ""
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(graph.imageRoot(id), layerIDFileName))
+	if err != nil || len(buf) == 0 {
+		return id
+	}
+	return strings.TrimSpace(string(buf))
+}
+
+// storeLayerRefs records the chain ID and driver layer of the image id in
+// its image directory root.
+func (graph *Graph) storeLayerRefs(root, id, layer, chain string) error {
+	if chain != "" {
+		if err := ioutil.WriteFile(filepath.Join(root, chainIDFileName), []byte(chain), 0600); err != nil {
+			return err
+		}
+	}
+	if layer != id {
+		if err := ioutil.WriteFile(filepath.Join(root, layerIDFileName), []byte(layer), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupLayer returns the driver layer storing the given chain of layers.
+func (graph *Graph) lookupLayer(chain string) (string, bool) {
+	if chain == "" {
+		return "", false
+	}
+	graph.layersMutex.Lock()
+	defer graph.layersMutex.Unlock()
+	layer, ok := graph.layers[chain]
+	if ok && !graph.driver.Exists(layer) {
+		return "", false
+	}
+	return layer, ok
+}
+
+// addLayerRef records that the image id uses the given driver layer.
+func (graph *Graph) addLayerRef(id, layer string) {
+	chain, _ := graph.getChainID(id)
+
+	graph.layersMutex.Lock()
+	defer graph.layersMutex.Unlock()
+	graph.layerRefs[layer]++
+	if _, exists := graph.layers[chain]; chain != "" && !exists {
+		graph.layers[chain] = layer
+	}
+}
+
+// removeLayerRef drops a reference to the given driver layer, holding the
+// given chain of layers. It returns true if the layer is no longer used.
+func (graph *Graph) removeLayerRef(layer, chain string) bool {
+	graph.layersMutex.Lock()
+	defer graph.layersMutex.Unlock()
+	graph.layerRefs[layer]--
+	if graph.layerRefs[layer] > 0 {
+		return false
+	}
+	delete(graph.layerRefs, layer)
+	if graph.layers[chain] == layer {
+		delete(graph.layers, chain)
+	}
+	return true
+}
+
+// resolveID returns the full ID of the image with the given ID, ID prefix,
+// alias or alias prefix.
+func (graph *Graph) resolveID(name string) (string, error) {
+	id, err := graph.idIndex.Get(name)
+	if err == nil || err == truncindex.ErrAmbiguousPrefix {
+		return id, err
+	}
+	graph.aliasesMutex.RLock()
+	defer graph.aliasesMutex.RUnlock()
+	if target, ok := graph.aliases[name]; ok {
+		return target, nil
+	}
+	alias, aliasErr := graph.aliasIndex.Get(name)
+	if aliasErr == truncindex.ErrAmbiguousPrefix {
+		return "", aliasErr
+	}
+	if aliasErr != nil {
+		return "", err
+	}
+	return graph.aliases[alias], nil
+}
+
+// resolveAlias returns the ID the given ID is an alias of, or the ID itself.
+func (graph *Graph) resolveAlias(id string) string {
+	graph.aliasesMutex.RLock()
+	defer graph.aliasesMutex.RUnlock()
+	if target, ok := graph.aliases[id]; ok {
+		return target
+	}
+	return id
+}
+
+// addAlias makes alias refer to the image id.
+func (graph *Graph) addAlias(alias, id string) error {
+	if alias == "" || alias == id {
+		return nil
+	}
+	graph.aliasesMutex.Lock()
+	defer graph.aliasesMutex.Unlock()
+	if _, exists := graph.aliases[alias]; !exists {
+		if err := graph.aliasIndex.Add(alias); err != nil {
+			return err
+		}
+	}
+	graph.aliases[alias] = id
+	return graph.saveAliases()
+}
+
+// removeAliases removes all aliases referring to the image id.
+func (graph *Graph) removeAliases(id string) error {
+	graph.aliasesMutex.Lock()
+	defer graph.aliasesMutex.Unlock()
+	changed := false
+	for alias, target := range graph.aliases {
+		if target == id {
+			delete(graph.aliases, alias)
+			graph.aliasIndex.Delete(alias)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return graph.saveAliases()
+}
+
+func (graph *Graph) loadAliases() error {
+	buf, err := ioutil.ReadFile(filepath.Join(graph.root, aliasesFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(buf, &graph.aliases); err != nil {
+		return err
+	}
+	aliases := make([]string, 0, len(graph.aliases))
+	for alias := range graph.aliases {
+		aliases = append(aliases, alias)
+	}
+	graph.aliasIndex = truncindex.NewTruncIndex(aliases)
+	return nil
+}
+
+// saveAliases writes the aliases to disk. The caller must hold
+// aliasesMutex.
+func (graph *Graph) saveAliases() error {
+	buf, err := json.Marshal(graph.aliases)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(graph.root, aliasesFileName)
+	if err := ioutil.WriteFile(path+".tmp", buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
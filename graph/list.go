@@ -5,6 +5,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/sara-nl/docker-1.9.1/api/types"
@@ -14,8 +15,9 @@ import (
 )
 
 var acceptedImageFilterTags = map[string]struct{}{
-	"dangling": {},
-	"label":    {},
+	"dangling":     {},
+	"label":        {},
+	"unused-since": {},
 }
 
 // byCreated is a temporary type used to sort a list of images by creation
@@ -61,6 +63,19 @@ func (s *TagStore) Images(filterArgs, filter string, all bool) ([]*types.Image,
 
 	_, filtLabel = imageFilters["label"]
 
+	// unusedSince holds the time after which the listed images must not
+	// have been used.
+	var unusedSince time.Time
+	for _, value := range imageFilters["unused-since"] {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("Invalid filter 'unused-since=%s'", value)
+		}
+		if t := time.Now().Add(-d); unusedSince.IsZero() || t.Before(unusedSince) {
+			unusedSince = t
+		}
+	}
+
 	if all && filtTagged {
 		allImages = s.graph.Map()
 	} else {
@@ -121,8 +136,11 @@ func (s *TagStore) Images(filterArgs, filter string, all bool) ([]*types.Image,
 						continue
 					}
 				}
+				if !s.unusedSince(id, unusedSince) {
+					continue
+				}
 				if filtTagged {
-					newImage := newImage(image, s.graph.GetParentsSize(image))
+					newImage := newImage(image, s.graph.GetParentsSize(image), s.imageUsage(id))
 
 					if utils.DigestReference(ref) {
 						newImage.RepoTags = []string{}
@@ -158,7 +176,10 @@ func (s *TagStore) Images(filterArgs, filter string, all bool) ([]*types.Image,
 					continue
 				}
 			}
-			newImage := newImage(image, s.graph.GetParentsSize(image))
+			if !s.unusedSince(image.ID, unusedSince) {
+				continue
+			}
+			newImage := newImage(image, s.graph.GetParentsSize(image), s.imageUsage(image.ID))
 			newImage.RepoTags = []string{"<none>:<none>"}
 			newImage.RepoDigests = []string{"<none>@<none>"}
 
@@ -171,13 +192,44 @@ func (s *TagStore) Images(filterArgs, filter string, all bool) ([]*types.Image,
 	return images, nil
 }
 
-func newImage(image *image.Image, parentSize int64) *types.Image {
+// unusedSince returns whether the image with the given id was not used by a
+// container since the given time. A zero time matches all images.
+func (s *TagStore) unusedSince(id string, since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+	lastUsed, err := s.graph.LastUsed(id)
+	if err != nil {
+		logrus.Warnf("couldn't determine when %s was last used: %s", id, err)
+		return false
+	}
+	return lastUsed.Before(since)
+}
+
+// imageUsage returns the usage statistics of the image with the given id,
+// logging rather than failing if they can not be read.
+func (s *TagStore) imageUsage(id string) ImageUsage {
+	usage, err := s.graph.GetUsage(id)
+	if err != nil {
+		logrus.Warnf("couldn't load usage of %s: %s", id, err)
+	}
+	return usage
+}
+
+func newImage(image *image.Image, parentSize int64, usage ImageUsage) *types.Image {
 	newImage := new(types.Image)
 	newImage.ParentID = image.Parent
 	newImage.ID = image.ID
 	newImage.Created = image.Created.Unix()
 	newImage.Size = image.Size
 	newImage.VirtualSize = parentSize + image.Size
+	if !usage.LastUsed.IsZero() {
+		newImage.LastUsed = usage.LastUsed.Unix()
+	}
+	if !usage.LastPulled.IsZero() {
+		newImage.LastPulled = usage.LastPulled.Unix()
+	}
+	newImage.UseCount = usage.UseCount
 	if image.Config != nil {
 		newImage.Labels = image.Config.Labels
 	}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sara-nl/docker-1.9.1/pkg/parsers/filters"
+	"github.com/sara-nl/docker-1.9.1/utils"
+)
+
+func TestImagesUnusedSince(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	if err := store.graph.MarkUsed(testOfficialImageID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// the unused image was added to the graph long ago
+	unused := createTestImage(store.graph, t)
+	if err := writeTimeFile(store.graph.imageRoot(unused.ID), addedFileName, time.Now().Add(-96*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	filterArgs, err := filters.ToParam(filters.Args{"unused-since": {"72h"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err := store.Images(filterArgs, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].ID != unused.ID {
+		t.Fatalf("Expected only the unused image %s, got %v", unused.ID, images)
+	}
+
+	images, err = store.Images("", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, img := range images {
+		if img.ID == mustResolveID(store, testOfficialImageID, t) && (img.UseCount != 1 || img.LastUsed == 0) {
+			t.Fatalf("Expected the usage of %s to be listed, got %+v", testOfficialImageIDShort, img)
+		}
+	}
+
+	filterArgs, err = filters.ToParam(filters.Args{"unused-since": {"yesterday"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Images(filterArgs, "", false); err == nil {
+		t.Fatal("Expected an error for an invalid unused-since filter")
+	}
+}
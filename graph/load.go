@@ -4,6 +4,7 @@ package graph
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -13,10 +14,12 @@ import (
 	"github.com/sara-nl/docker-1.9.1/image"
 	"github.com/sara-nl/docker-1.9.1/pkg/archive"
 	"github.com/sara-nl/docker-1.9.1/pkg/chrootarchive"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
 )
 
 // Load uploads a set of images into the repository. This is the complementary of ImageExport.
-// The input stream is an uncompressed tar ball containing images and metadata.
+// The input stream is an uncompressed tar ball containing images and metadata, in
+// either of the formats ImageExport writes.
 func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
 	tmpImageDir, err := ioutil.TempDir("", "docker-import-")
 	if err != nil {
@@ -42,11 +45,26 @@ func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
 		return err
 	}
 
+	if isBundle(repoDir) {
+		return s.loadBundle(repoDir, outStream)
+	}
+	// Only bundles carry signed manifests, so other archives could be used
+	// to get around the signature checks.
+	if s.requireTrustedBundles {
+		return fmt.Errorf("the archive is not an image bundle, and only bundles signed by a trusted key can be loaded")
+	}
+
 	dirs, err := ioutil.ReadDir(repoDir)
 	if err != nil {
 		return err
 	}
 
+	// An archive saved without the layers of a base image can only be
+	// loaded on top of that image.
+	if err := s.checkBaseLayers(repoDir, dirs); err != nil {
+		return err
+	}
+
 	for _, d := range dirs {
 		if d.IsDir() {
 			if err := s.recursiveLoad(d.Name(), tmpImageDir); err != nil {
@@ -80,6 +98,32 @@ func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
 	return nil
 }
 
+// checkBaseLayers returns an error if an image in the archive extracted to
+// repoDir has a parent that is neither in the archive nor in the graph.
+func (s *TagStore) checkBaseLayers(repoDir string, dirs []os.FileInfo) error {
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		imageJSON, err := ioutil.ReadFile(filepath.Join(repoDir, d.Name(), "json"))
+		if err != nil {
+			return err
+		}
+		img, err := image.NewImgJSON(imageJSON)
+		if err != nil {
+			return err
+		}
+		if img.Parent == "" || s.graph.Exists(img.Parent) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, img.Parent, "json")); err == nil {
+			continue
+		}
+		return fmt.Errorf("Image %s is missing its parent layer %s, which was excluded from the archive. Load or pull the base image it was saved against first.", stringid.TruncateID(d.Name()), stringid.TruncateID(img.Parent))
+	}
+	return nil
+}
+
 func (s *TagStore) recursiveLoad(address, tmpImageDir string) error {
 	if _, err := s.LookupImage(address); err != nil {
 		logrus.Debugf("Loading %s", address)
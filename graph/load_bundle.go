@@ -0,0 +1,202 @@
+// +build linux windows
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest"
+	"github.com/docker/libtrust"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/utils"
+)
+
+// isBundle returns whether the archive extracted to dir is an image bundle.
+func isBundle(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, bundleIndexFileName))
+	return err == nil
+}
+
+// loadBundle loads the images of the image bundle extracted to dir, and
+// tags them with the names they were exported under. The digests of the
+// manifests and blobs, and the signatures of signed manifests, are verified
+// before the images they describe are registered.
+func (s *TagStore) loadBundle(dir string, outStream io.Writer) error {
+	indexJSON, err := ioutil.ReadFile(filepath.Join(dir, bundleIndexFileName))
+	if err != nil {
+		return err
+	}
+	var index bundleIndex
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return fmt.Errorf("invalid bundle index: %v", err)
+	}
+	if index.SchemaVersion != 1 {
+		return fmt.Errorf("unsupported bundle schema version %d", index.SchemaVersion)
+	}
+
+	for _, bm := range index.Manifests {
+		id, err := s.loadBundleManifest(dir, bm, outStream)
+		if err != nil {
+			return err
+		}
+		if bm.Name != "" {
+			if err := s.setLoad(bm.Name, bm.Tag, id, true, outStream); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadBundleManifest loads the image of the manifest bm of the bundle in
+// dir, and returns its ID. The images get the IDs they would get if the
+// manifest was pulled from a registry.
+func (s *TagStore) loadBundleManifest(dir string, bm bundleManifest, outStream io.Writer) (string, error) {
+	if err := bm.Digest.Validate(); err != nil {
+		return "", fmt.Errorf("invalid manifest digest %q: %v", bm.Digest, err)
+	}
+	name := bm.Digest.String()
+	if bm.Name != "" {
+		name = utils.ImageReference(bm.Name, bm.Tag)
+	}
+
+	manifestJSON, err := ioutil.ReadFile(filepath.Join(dir, "manifests", bm.Digest.Hex()))
+	if err != nil {
+		return "", err
+	}
+	var signedManifest manifest.SignedManifest
+	if err := json.Unmarshal(manifestJSON, &signedManifest); err != nil {
+		return "", fmt.Errorf("invalid manifest for %s: %v", name, err)
+	}
+	if err := s.verifyBundleSignatures(&signedManifest, name, outStream); err != nil {
+		return "", err
+	}
+
+	// verifyManifest checks the manifest against its digest.
+	m, err := verifyManifest(&signedManifest, bm.Digest.String())
+	if err != nil {
+		return "", err
+	}
+	if err := fixManifestLayers(m); err != nil {
+		return "", err
+	}
+
+	// Reuse the ID computation of pulls, so that loading a bundle and
+	// pulling the same manifest result in the same images.
+	imgs, err := (&v2Puller{TagStore: s}).getImageInfos(m)
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(imgs) - 1; i >= 0; i-- {
+		img := imgs[i]
+		if s.graph.Exists(img.id) {
+			logrus.Debugf("Image already exists: %s", img.id)
+			continue
+		}
+
+		if err := s.loadBundleLayer(dir, img); err != nil {
+			return "", err
+		}
+	}
+	return imgs[0].id, nil
+}
+
+// verifyBundleSignatures verifies the signatures of the manifest sm of a
+// bundle, and reports the keys that signed it. Manifests that are unsigned,
+// or only signed by keys the daemon does not trust, are rejected if the
+// daemon requires trusted bundles.
+func (s *TagStore) verifyBundleSignatures(sm *manifest.SignedManifest, name string, outStream io.Writer) error {
+	if _, err := sm.Signatures(); err != nil {
+		if s.requireTrustedBundles {
+			return fmt.Errorf("the manifest for %s is not signed, and only bundles signed by a trusted key can be loaded", name)
+		}
+		return nil
+	}
+	keys, err := manifest.Verify(sm)
+	if err != nil {
+		return fmt.Errorf("invalid signature of the manifest for %s: %v", name, err)
+	}
+
+	var trusted bool
+	for _, key := range keys {
+		if s.isTrustedKey(key) {
+			trusted = true
+			fmt.Fprintf(outStream, "Verified signature of %s by trusted key %s\n", name, key.KeyID())
+		} else {
+			fmt.Fprintf(outStream, "Verified signature of %s by untrusted key %s\n", name, key.KeyID())
+		}
+	}
+	if !trusted && s.requireTrustedBundles {
+		return fmt.Errorf("the manifest for %s is not signed by a trusted key", name)
+	}
+	return nil
+}
+
+// isTrustedKey returns whether key is the key of the daemon or one of the
+// keys it trusts to sign bundles.
+func (s *TagStore) isTrustedKey(key libtrust.PublicKey) bool {
+	if s.trustKey != nil && s.trustKey.KeyID() == key.KeyID() {
+		return true
+	}
+	for _, trusted := range s.trustedKeys {
+		if trusted.KeyID() == key.KeyID() {
+			return true
+		}
+	}
+	return false
+}
+
+// loadBundleLayer verifies the blob of the layer of img in the bundle in dir,
+// and registers the image.
+func (s *TagStore) loadBundleLayer(dir string, img contentAddressableDescriptor) error {
+	if err := img.blobSum.Validate(); err != nil {
+		return fmt.Errorf("invalid layer digest %q: %v", img.blobSum, err)
+	}
+	blob, err := os.Open(blobPath(dir, img.blobSum))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("Image %s is missing its layer %s, which was excluded from the bundle. Load or pull the base image it was saved against first.", stringid.TruncateID(img.id), img.blobSum)
+		}
+		return err
+	}
+	defer blob.Close()
+
+	verifier, err := digest.NewDigestVerifier(img.blobSum)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(verifier, blob); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("filesystem layer verification failed for digest %s", img.blobSum)
+	}
+	if _, err := blob.Seek(0, 0); err != nil {
+		return err
+	}
+
+	s.graph.imagesMutex.Lock()
+	defer s.graph.imagesMutex.Unlock()
+
+	s.graph.imageMutex.Lock(img.id)
+	defer s.graph.imageMutex.Unlock(img.id)
+
+	if s.graph.Exists(img.id) {
+		return nil
+	}
+	if _, err := s.graph.register(img, blob); err != nil {
+		return err
+	}
+	if err := s.graph.setLayerDigest(img.id, img.blobSum); err != nil {
+		return err
+	}
+	return s.graph.setV1CompatibilityConfig(img.id, img.v1Compatibility)
+}
@@ -0,0 +1,297 @@
+// +build linux windows
+
+package graph
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/libtrust"
+	"github.com/sara-nl/docker-1.9.1/daemon/events"
+)
+
+func TestCheckBaseLayers(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	store, err := NewTagStore(path.Join(graph.root, "tags"), &TagStoreConfig{Graph: graph, Events: events.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := registerLayer(graph, "", []string{"base"}, nil, t)
+
+	repoDir, err := ioutil.TempDir("", "docker-load-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+
+	addImage := func(id, parent string) {
+		if err := os.Mkdir(filepath.Join(repoDir, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+		config := `{"id":"` + id + `","parent":"` + parent + `"}`
+		if err := ioutil.WriteFile(filepath.Join(repoDir, id, "json"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func() error {
+		dirs, err := ioutil.ReadDir(repoDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store.checkBaseLayers(repoDir, dirs)
+	}
+
+	// Parents in the graph or in the archive are fine.
+	addImage(strings.Repeat("a", 64), base.ID)
+	addImage(strings.Repeat("b", 64), strings.Repeat("a", 64))
+	if err := check(); err != nil {
+		t.Fatal(err)
+	}
+
+	addImage(strings.Repeat("c", 64), strings.Repeat("d", 64))
+	if err := check(); err == nil {
+		t.Fatal("Expected an error for a missing base layer")
+	}
+}
+
+func TestLoadBundle(t *testing.T) {
+	key, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	store, err := NewTagStore(path.Join(graph.root, "tags"), &TagStoreConfig{Graph: graph, Key: key, Events: events.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := registerLayer(graph, "", []string{"base"}, nil, t)
+	app := registerLayer(graph, base.ID, []string{"app"}, nil, t)
+	if err := store.Tag("base", "", base.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag("app", "", app.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(config *ImageExportConfig) []byte {
+		return exportBundle(store, config, t)
+	}
+	newStore := func() (*TagStore, func()) {
+		return newBundleTestStore(&TagStoreConfig{Key: key}, t)
+	}
+
+	signed := export(&ImageExportConfig{Names: []string{"app"}, Sign: true})
+	target, cleanup := newStore()
+	defer cleanup()
+	out, err := loadBundleArchive(target, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Verified signature of app:latest by trusted key "+key.KeyID()) {
+		t.Fatalf("Expected the signature of the manifest to be verified, got %q", out)
+	}
+	img, err := target.LookupImage("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := layerFiles(target.graph, img, t); !files["/app"] {
+		t.Fatalf("Expected the loaded layer to hold /app, got %v", files)
+	}
+
+	// A blob that does not match its digest is rejected.
+	target, cleanup = newStore()
+	defer cleanup()
+	if _, err := loadBundleArchive(target, rewriteTar(signed, "blobs/", t)); err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Fatalf("Expected a layer verification error, got %v", err)
+	}
+
+	// So is a manifest that does not match its signature.
+	target, cleanup = newStore()
+	defer cleanup()
+	if _, err := loadBundleArchive(target, rewriteTar(signed, "manifests/", t)); err == nil {
+		t.Fatal("Expected an error for a modified manifest")
+	}
+
+	// A bundle without the layers of its base image can only be loaded
+	// where the base image is present.
+	incremental := export(&ImageExportConfig{Names: []string{"app"}, ExcludeBase: "base"})
+	target, cleanup = newStore()
+	defer cleanup()
+	if _, err := loadBundleArchive(target, incremental); err == nil || !strings.Contains(err.Error(), "excluded from the bundle") {
+		t.Fatalf("Expected an error for the missing base layer, got %v", err)
+	}
+	if _, err := loadBundleArchive(target, export(&ImageExportConfig{Names: []string{"base"}})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadBundleArchive(target, incremental); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.LookupImage("app"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadBundleTrust(t *testing.T) {
+	key, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	store, err := NewTagStore(path.Join(graph.root, "tags"), &TagStoreConfig{Graph: graph, Key: key, Events: events.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := registerLayer(graph, "", []string{"app"}, nil, t)
+	if err := store.Tag("app", "", app.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := exportBundle(store, &ImageExportConfig{Names: []string{"app"}}, t)
+	signed := exportBundle(store, &ImageExportConfig{Names: []string{"app"}, Sign: true}, t)
+	tampered := rewriteTar(signed, "manifests/", t)
+	store.trustKey = otherKey
+	wrongKey := exportBundle(store, &ImageExportConfig{Names: []string{"app"}, Sign: true}, t)
+
+	// By default, unsigned bundles and bundles signed by untrusted keys
+	// are loaded.
+	target, cleanup := newBundleTestStore(&TagStoreConfig{}, t)
+	defer cleanup()
+	if _, err := loadBundleArchive(target, unsigned); err != nil {
+		t.Fatal(err)
+	}
+	out, err := loadBundleArchive(target, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Verified signature of app:latest by untrusted key "+key.KeyID()) {
+		t.Fatalf("Expected the signing key to be reported as untrusted, got %q", out)
+	}
+
+	// A daemon requiring trusted bundles only loads bundles signed by its
+	// own key or one of the keys it trusts.
+	for _, cfg := range []*TagStoreConfig{
+		{TrustedKeys: []libtrust.PublicKey{key.PublicKey()}, RequireTrustedBundles: true},
+		{Key: key, RequireTrustedBundles: true},
+	} {
+		target, cleanup := newBundleTestStore(cfg, t)
+		defer cleanup()
+
+		if _, err := loadBundleArchive(target, unsigned); err == nil || !strings.Contains(err.Error(), "is not signed") {
+			t.Fatalf("Expected an unsigned bundle to be rejected, got %v", err)
+		}
+		if _, err := loadBundleArchive(target, wrongKey); err == nil || !strings.Contains(err.Error(), "not signed by a trusted key") {
+			t.Fatalf("Expected a bundle signed by an untrusted key to be rejected, got %v", err)
+		}
+		if _, err := loadBundleArchive(target, tampered); err == nil {
+			t.Fatal("Expected a tampered bundle to be rejected")
+		}
+		// Archives in the legacy format are not signed.
+		buf := new(bytes.Buffer)
+		if err := store.ImageExport(&ImageExportConfig{Names: []string{"app"}, Outstream: buf}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadBundleArchive(target, buf.Bytes()); err == nil || !strings.Contains(err.Error(), "not an image bundle") {
+			t.Fatalf("Expected a legacy archive to be rejected, got %v", err)
+		}
+		if _, err := target.LookupImage("app"); err == nil {
+			t.Fatal("Expected no image to be loaded from the rejected archives")
+		}
+
+		out, err := loadBundleArchive(target, signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "Verified signature of app:latest by trusted key "+key.KeyID()) {
+			t.Fatalf("Expected the signature to be verified by a trusted key, got %q", out)
+		}
+		if _, err := target.LookupImage("app"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// exportBundle returns the image bundle of store exported with config.
+func exportBundle(store *TagStore, config *ImageExportConfig, t *testing.T) []byte {
+	buf := new(bytes.Buffer)
+	config.Format = ExportFormatBundle
+	config.Outstream = buf
+	if err := store.ImageExport(config); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// loadBundleArchive loads bundle in target, and returns the output of the
+// load.
+func loadBundleArchive(target *TagStore, bundle []byte) (string, error) {
+	out := new(bytes.Buffer)
+	err := target.Load(ioutil.NopCloser(bytes.NewReader(bundle)), out)
+	return out.String(), err
+}
+
+// newBundleTestStore returns a tag store with an empty graph, configured
+// with cfg, and a function removing it.
+func newBundleTestStore(cfg *TagStoreConfig, t *testing.T) (*TagStore, func()) {
+	g, _ := tempGraph(t)
+	cfg.Graph = g
+	cfg.Events = events.New()
+	s, err := NewTagStore(path.Join(g.root, "tags"), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, func() { nukeGraph(g) }
+}
+
+// rewriteTar returns a copy of the tar archive arch in which the content of
+// the first regular file under prefix is altered.
+func rewriteTar(arch []byte, prefix string, t *testing.T) []byte {
+	buf := new(bytes.Buffer)
+	tr := tar.NewReader(bytes.NewReader(arch))
+	tw := tar.NewWriter(buf)
+	altered := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !altered && hdr.Typeflag == tar.TypeReg && strings.HasPrefix(hdr.Name, prefix) && len(data) > 0 {
+			data[len(data)/2] ^= 0xff
+			altered = true
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !altered {
+		t.Fatalf("No file under %s in the archive", prefix)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+)
+
+// migrate converts the images stored by older versions of the daemon, which
+// are identified by random IDs, to content-addressable IDs. The driver layers
+// of the migrated images keep their names, so containers created from them
+// are unaffected, and the old IDs remain usable as aliases.
+//
+// Images are migrated in place, parents first. An image is considered
+// migrated once its directory records the chain ID of its layers.
+func (graph *Graph) migrate() error {
+	dir, err := ioutil.ReadDir(graph.root)
+	if err != nil {
+		return err
+	}
+
+	legacy := make(map[string]*image.Image)
+	for _, v := range dir {
+		id := v.Name()
+		if !v.IsDir() || image.ValidateID(id) != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(graph.imageRoot(id), chainIDFileName)); err == nil {
+			continue
+		}
+		img, err := graph.loadImage(id)
+		if err != nil {
+			logrus.Warnf("ignoring image %s, it could not be migrated: %v", id, err)
+			continue
+		}
+		legacy[id] = img
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	logrus.Infof("Migrating %d images to content-addressable IDs", len(legacy))
+	migrated := make(map[string]string)
+	for id := range legacy {
+		if _, err := graph.migrateImage(id, legacy, migrated); err != nil {
+			return fmt.Errorf("image %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// migrateImage migrates the legacy image id after its parents, and returns
+// its new ID. migrated maps the IDs of the images migrated so far to their
+// new IDs.
+func (graph *Graph) migrateImage(id string, legacy map[string]*image.Image, migrated map[string]string) (string, error) {
+	if newID, ok := migrated[id]; ok {
+		return newID, nil
+	}
+	img := legacy[id]
+
+	parent := img.Parent
+	if parent != "" {
+		if _, ok := legacy[parent]; ok {
+			var err error
+			if parent, err = graph.migrateImage(parent, legacy, migrated); err != nil {
+				return "", err
+			}
+		} else {
+			parent = graph.resolveAlias(parent)
+		}
+	}
+
+	layerDigest, err := graph.legacyLayerDigest(img)
+	if err != nil {
+		return "", fmt.Errorf("unable to compute the layer digest: %v", err)
+	}
+	chain := graph.chainID(parent, layerDigest)
+
+	root := graph.imageRoot(id)
+	config, err := ioutil.ReadFile(jsonPath(root))
+	if err != nil {
+		return "", err
+	}
+
+	// Images pulled from a v2 registry already have a content-addressable
+	// ID, they only lack the chain ID.
+	if strongID, err := image.StrongID(config); err == nil && strongID.Hex() == id {
+		if err := ioutil.WriteFile(filepath.Join(root, chainIDFileName), []byte(chain), 0600); err != nil {
+			return "", err
+		}
+		migrated[id] = id
+		return id, nil
+	}
+
+	var parentID digest.Digest
+	if parent != "" {
+		parentID = digest.NewDigestFromHex(string(digest.Canonical), parent)
+	}
+	caConfig, err := image.MakeImageConfig(config, layerDigest, parentID)
+	if err != nil {
+		return "", err
+	}
+	strongID, err := image.StrongID(caConfig)
+	if err != nil {
+		return "", err
+	}
+	newID := strongID.Hex()
+
+	if _, err := os.Stat(graph.imageRoot(newID)); err == nil {
+		if graph.LayerID(newID) != id {
+			// Another image already has the same content. Both are
+			// kept so that the containers using their driver layers
+			// keep working, and this one keeps its ID.
+			logrus.Warnf("image %s has the same content as image %s, keeping its ID", stringid.TruncateID(id), stringid.TruncateID(newID))
+			if err := ioutil.WriteFile(filepath.Join(root, chainIDFileName), []byte(chain), 0600); err != nil {
+				return "", err
+			}
+			migrated[id] = id
+			return id, nil
+		}
+		// A previous migration was interrupted after the image was
+		// moved to its new ID.
+	} else {
+		if err := graph.moveLegacyImage(id, newID, chain, config, caConfig); err != nil {
+			return "", err
+		}
+	}
+
+	if err := graph.addAlias(id, newID); err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(root); err != nil {
+		return "", err
+	}
+	logrus.Debugf("Migrated image %s to %s", id, newID)
+	migrated[id] = newID
+	return newID, nil
+}
+
+// moveLegacyImage stores the legacy image id under newID. The new image
+// directory is prepared from hard links to the files of the old one, and
+// then renamed into place, so the old image is left intact until the
+// migration of the image is complete.
+func (graph *Graph) moveLegacyImage(id, newID, chain string, v1Config, caConfig []byte) error {
+	root := graph.imageRoot(id)
+	tmp, err := graph.mktemp()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		switch f.Name() {
+		case "json", parentFileName:
+			// Replaced below. The parent is part of the new
+			// configuration.
+			continue
+		}
+		if err := os.Link(filepath.Join(root, f.Name()), filepath.Join(tmp, f.Name())); err != nil {
+			return err
+		}
+	}
+
+	if err := ioutil.WriteFile(jsonPath(tmp), caConfig, 0600); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(tmp, v1CompatibilityFileName)); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(filepath.Join(tmp, v1CompatibilityFileName), v1Config, 0600); err != nil {
+			return err
+		}
+	}
+	if err := graph.storeLayerRefs(tmp, newID, id, chain); err != nil {
+		return err
+	}
+	return os.Rename(tmp, graph.imageRoot(newID))
+}
+
+// legacyLayerDigest returns the digest of the layer of a legacy image. The
+// digest recorded by a push or pull is used if available, otherwise it is
+// computed from the layer data.
+func (graph *Graph) legacyLayerDigest(img *image.Image) (digest.Digest, error) {
+	if dgst, err := graph.getLayerDigest(img.ID); err == nil {
+		return dgst, nil
+	}
+
+	arch, err := graph.TarLayer(img)
+	if err != nil {
+		return "", err
+	}
+	defer arch.Close()
+
+	digester := digest.Canonical.New()
+	if _, err := io.Copy(digester.Hash(), arch); err != nil {
+		return "", err
+	}
+	return digester.Digest(), nil
+}
+
+// migrateIDs rewrites the references to images that were migrated to
+// content-addressable IDs. It returns whether any reference changed.
+func (store *TagStore) migrateIDs() bool {
+	changed := false
+	for _, repo := range store.Repositories {
+		for ref, id := range repo {
+			if newID := store.graph.resolveAlias(id); newID != id {
+				repo[ref] = newID
+				changed = true
+			}
+		}
+	}
+	return changed
+}
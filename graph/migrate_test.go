@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sara-nl/docker-1.9.1/daemon/events"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+)
+
+// storeLegacyImage stores an image the way older versions of the graph did,
+// under its own random ID.
+func storeLegacyImage(graph *Graph, img *image.Image, t *testing.T) {
+	archive, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.driver.Create(img.ID, img.Parent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := graph.driver.ApplyDiff(img.ID, img.Parent, archive); err != nil {
+		t.Fatal(err)
+	}
+	root := graph.imageRoot(img.ID)
+	if err := os.MkdirAll(root, 0700); err != nil {
+		t.Fatal(err)
+	}
+	config, err := json.Marshal(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(jsonPath(root), config, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	graph, driver := tempGraph(t)
+	defer nukeGraph(graph)
+
+	parent := &image.Image{
+		ID:      stringid.GenerateNonCryptoID(),
+		Comment: "parent",
+		Created: time.Now(),
+	}
+	child := &image.Image{
+		ID:      stringid.GenerateNonCryptoID(),
+		Parent:  parent.ID,
+		Comment: "child",
+		Created: time.Now(),
+	}
+	storeLegacyImage(graph, parent, t)
+	storeLegacyImage(graph, child, t)
+
+	tagCfg := &TagStoreConfig{Graph: graph, Events: events.New()}
+	store, err := NewTagStore(path.Join(graph.root, "tags"), tagCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Repositories["foo"] = Repository{"latest": child.ID}
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	graph, err = NewGraph(graph.root, driver, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNImages(graph, t, 2)
+
+	migratedChild, err := graph.Get(child.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migratedChild.ID == child.ID {
+		t.Fatal("Expected the image to get a content-addressable ID")
+	}
+	migratedParent, err := graph.Get(parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migratedChild.Parent != migratedParent.ID {
+		t.Fatalf("Expected parent %s, got %s", migratedParent.ID, migratedChild.Parent)
+	}
+	if _, err := os.Stat(filepath.Join(graph.root, child.ID)); !os.IsNotExist(err) {
+		t.Fatalf("Expected the legacy image directory to be removed, got %v", err)
+	}
+
+	// The driver layers keep their names.
+	if layer := graph.LayerID(migratedChild.ID); layer != child.ID {
+		t.Fatalf("Expected driver layer %s, got %s", child.ID, layer)
+	}
+	if _, err := graph.TarLayer(migratedChild); err != nil {
+		t.Fatal(err)
+	}
+
+	// The ID follows from the content of the image.
+	config, err := graph.RawJSON(migratedChild.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strongID, err := image.StrongID(config); err != nil || strongID.Hex() != migratedChild.ID {
+		t.Fatalf("Expected ID %s to be the digest of the configuration, got %s (%v)", migratedChild.ID, strongID, err)
+	}
+
+	tagCfg.Graph = graph
+	store, err = NewTagStore(path.Join(graph.root, "tags"), tagCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := store.Repositories["foo"]["latest"]; id != migratedChild.ID {
+		t.Fatalf("Expected tag to be updated to %s, got %s", migratedChild.ID, id)
+	}
+
+	// Migrating again changes nothing.
+	graph, err = NewGraph(graph.root, driver, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img, err := graph.Get(child.ID); err != nil || img.ID != migratedChild.ID {
+		t.Fatalf("Expected %s after a second migration, got %v (%v)", migratedChild.ID, img, err)
+	}
+}
@@ -222,6 +222,9 @@ func (p *v1Puller) pullRepository(askedTag string) error {
 		if err := p.Tag(p.repoInfo.LocalName, tag, id, true); err != nil {
 			return err
 		}
+		if err := p.graph.SetLastPulled(id, time.Now()); err != nil {
+			logrus.Warnf("Unable to record pull of image %s: %v", stringid.TruncateID(id), err)
+		}
 	}
 
 	requestedTag := p.repoInfo.LocalName
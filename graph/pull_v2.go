@@ -5,8 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution"
@@ -29,20 +30,30 @@ type v2Puller struct {
 	sf        *streamformatter.StreamFormatter
 	repoInfo  *registry.RepositoryInfo
 	repo      distribution.Repository
+	transport http.RoundTripper
 	sessionID string
 }
 
 func (p *v2Puller) Pull(tag string) (fallback bool, err error) {
 	// TODO(tiborvass): was ReceiveTimeout
-	p.repo, err = NewV2Repository(p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
+	var a *v2Auth
+	p.repo, a, err = newV2Repository(p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
 	if err != nil {
 		logrus.Warnf("Error getting v2 registry: %v", err)
 		return true, err
 	}
+	p.transport = a.transport
 
 	p.sessionID = stringid.GenerateRandomID()
 
 	if err := p.pullV2Repository(tag); err != nil {
+		if p.endpoint.Mirror {
+			// A mirror that fails for any reason, such as a stale or
+			// incomplete cache, should not prevent pulling from the
+			// registry itself.
+			logrus.Warnf("Error pulling %s from mirror %s, falling back: %v", p.repoInfo.LocalName, p.endpoint.URL, err)
+			return true, err
+		}
 		if registry.ContinueOnError(err) {
 			logrus.Debugf("Error trying v2 registry: %v", err)
 			return true, err
@@ -109,7 +120,6 @@ type downloadInfo struct {
 	imgIndex    int
 	tmpFile     *os.File
 	digest      digest.Digest
-	layer       distribution.ReadSeekCloser
 	size        int64
 	err         chan error
 	poolKey     string
@@ -123,12 +133,14 @@ type contentAddressableDescriptor struct {
 	parent          string
 	strongID        digest.Digest
 	compatibilityID string
+	blobSum         digest.Digest
 	config          []byte
 	v1Compatibility []byte
 }
 
 func newContentAddressableImage(v1Compatibility []byte, blobSum digest.Digest, parent digest.Digest) (contentAddressableDescriptor, error) {
 	img := contentAddressableDescriptor{
+		blobSum:         blobSum,
 		v1Compatibility: v1Compatibility,
 	}
 
@@ -170,6 +182,11 @@ func (img contentAddressableDescriptor) MarshalConfig() ([]byte, error) {
 	return img.config, nil
 }
 
+// LayerDigest returns the digest of the layer blob of the image.
+func (img contentAddressableDescriptor) LayerDigest() digest.Digest {
+	return img.blobSum
+}
+
 type errVerification struct{}
 
 func (errVerification) Error() string { return "verification failed" }
@@ -177,6 +194,12 @@ func (errVerification) Error() string { return "verification failed" }
 func (p *v2Puller) download(di *downloadInfo) {
 	logrus.Debugf("pulling blob %q to %s", di.digest, di.img.id)
 
+	if !p.downloads.tryAcquire() {
+		di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.id), "Waiting", nil))
+		p.downloads.acquire()
+	}
+	defer p.downloads.release()
+
 	blobs := p.repo.Blobs(context.Background())
 
 	desc, err := blobs.Stat(context.Background(), di.digest)
@@ -187,48 +210,87 @@ func (p *v2Puller) download(di *downloadInfo) {
 	}
 	di.size = desc.Size
 
-	layerDownload, err := blobs.Open(context.Background(), di.digest)
+	p.graph.blobMutex.Lock(string(di.digest))
+	defer p.graph.blobMutex.Unlock(string(di.digest))
+
+	// Resume the download of the blob a previous pull left behind.
+	offset, err := di.tmpFile.Seek(0, os.SEEK_END)
 	if err != nil {
-		logrus.Debugf("Error fetching layer: %v", err)
 		di.err <- err
 		return
 	}
-	defer layerDownload.Close()
+	if offset > di.size {
+		offset = 0
+	}
+	if offset < di.size || di.size <= 0 {
+		if err := p.fetchBlob(di, offset); err != nil {
+			logrus.Debugf("Error downloading layer: %v", err)
+			di.err <- err
+			return
+		}
+	}
+
+	di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.id), "Verifying Checksum", nil))
 
+	// Verify the whole blob, including the part of an earlier download.
 	verifier, err := digest.NewDigestVerifier(di.digest)
 	if err != nil {
 		di.err <- err
 		return
 	}
-
-	reader := progressreader.New(progressreader.Config{
-		In:        ioutil.NopCloser(io.TeeReader(layerDownload, verifier)),
-		Out:       di.broadcaster,
-		Formatter: p.sf,
-		Size:      di.size,
-		NewLines:  false,
-		ID:        stringid.TruncateID(di.img.id),
-		Action:    "Downloading",
-	})
-	io.Copy(di.tmpFile, reader)
-
-	di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.id), "Verifying Checksum", nil))
-
+	if _, err := di.tmpFile.Seek(0, 0); err != nil {
+		di.err <- err
+		return
+	}
+	if _, err := io.Copy(verifier, di.tmpFile); err != nil {
+		di.err <- err
+		return
+	}
 	if !verifier.Verified() {
 		err = fmt.Errorf("filesystem layer verification failed for digest %s", di.digest)
 		logrus.Error(err)
+		// Don't resume from corrupt data.
+		di.tmpFile.Truncate(0)
 		di.err <- err
 		return
 	}
 
 	di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.id), "Download complete", nil))
 
-	logrus.Debugf("Downloaded %s to tempfile %s", di.img.id, di.tmpFile.Name())
-	di.layer = layerDownload
+	logrus.Debugf("Downloaded %s to %s", di.img.id, di.tmpFile.Name())
 
 	di.err <- nil
 }
 
+// fetchBlob downloads the blob of di to its file, from offset on.
+func (p *v2Puller) fetchBlob(di *downloadInfo, offset int64) error {
+	layerDownload, offset, err := p.openBlob(di.digest, offset, di.size)
+	if err != nil {
+		return err
+	}
+	defer layerDownload.Close()
+
+	if err := di.tmpFile.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := di.tmpFile.Seek(offset, 0); err != nil {
+		return err
+	}
+
+	reader := progressreader.New(progressreader.Config{
+		In:        layerDownload,
+		Out:       di.broadcaster,
+		Formatter: p.sf,
+		Size:      di.size,
+		Current:   offset,
+		NewLines:  false,
+		ID:        stringid.TruncateID(di.img.id),
+		Action:    "Downloading",
+	})
+	_, err = io.Copy(di.tmpFile, reader)
+	return err
+}
+
 func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated bool, err error) {
 	logrus.Debugf("Pulling tag from V2 registry: %q", tag)
 
@@ -271,11 +333,10 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 
 		for _, d := range downloads {
 			p.poolRemoveWithError("pull", d.poolKey, err)
+			// Keep the blobs of the layers that were not registered,
+			// the next pull resumes their download.
 			if d.tmpFile != nil {
 				d.tmpFile.Close()
-				if err := os.RemoveAll(d.tmpFile.Name()); err != nil {
-					logrus.Errorf("Failed to remove temp file: %s", d.tmpFile.Name())
-				}
 			}
 		}
 	}()
@@ -313,12 +374,6 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 			err: make(chan error, 1),
 		}
 
-		tmpFile, err := ioutil.TempFile("", "GetImageBlob")
-		if err != nil {
-			return false, err
-		}
-		d.tmpFile = tmpFile
-
 		downloads = append(downloads, d)
 
 		broadcaster, found := p.poolAdd("pull", d.poolKey)
@@ -327,6 +382,11 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 		if found {
 			d.err <- nil
 		} else {
+			tmpFile, err := p.graph.openPartialBlob(d.digest)
+			if err != nil {
+				return false, err
+			}
+			d.tmpFile = tmpFile
 			go p.download(d)
 		}
 	}
@@ -336,7 +396,7 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 			return false, err
 		}
 
-		if d.layer == nil {
+		if d.tmpFile == nil {
 			// Wait for a different pull to download and extract
 			// this layer.
 			err = d.broadcaster.Wait()
@@ -374,7 +434,7 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 				}
 			}
 
-			if err := p.graph.register(d.img, reader); err != nil {
+			if _, err := p.graph.register(d.img, reader); err != nil {
 				return err
 			}
 
@@ -392,6 +452,13 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 			return false, err
 		}
 
+		// The layer is registered, its blob is no longer needed.
+		d.tmpFile.Close()
+		if err := os.Remove(d.tmpFile.Name()); err != nil {
+			logrus.Errorf("Failed to remove downloaded blob: %s", d.tmpFile.Name())
+		}
+		d.tmpFile = nil
+
 		d.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(d.img.id), "Pull complete", nil))
 		d.broadcaster.Close()
 		tagUpdated = true
@@ -432,6 +499,14 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (tagUpdated
 			return false, err
 		}
 	}
+	if err := p.graph.SetLastPulled(firstID, time.Now()); err != nil {
+		logrus.Warnf("Unable to record pull of image %s: %v", stringid.TruncateID(firstID), err)
+	}
+	for _, img := range imgs {
+		if err := p.graph.AddBlobSource(img.blobSum, p.repoInfo.Index.Name, p.repo.Name()); err != nil {
+			logrus.Warnf("Unable to record the source of blob %s: %v", img.blobSum, err)
+		}
+	}
 
 	if manifestDigest != "" {
 		out.Write(p.sf.FormatStatus("", "Digest: %s", manifestDigest))
@@ -553,6 +628,8 @@ func (p *v2Puller) getImageInfos(m *manifest.Manifest) ([]contentAddressableDesc
 
 // attemptIDReuse does a best attempt to match verified compatibilityIDs
 // already in the graph with the computed strongIDs so we can keep using them.
+// Images stored under their compatibilityIDs by older versions were migrated
+// to content-addressable IDs, and their compatibilityIDs are aliases of them.
 // This process will never fail but may just return the strongIDs if none of
 // the compatibilityIDs exists or can be verified. If the strongIDs themselves
 // fail verification, we deterministically generate alternate IDs to use until
@@ -565,12 +642,13 @@ func (p *v2Puller) attemptIDReuse(imgs []contentAddressableDescriptor) {
 	defer p.graph.imagesMutex.Unlock()
 
 	idMap := make(map[string]struct{})
-	for _, img := range imgs {
+	for i, img := range imgs {
+		imgs[i].compatibilityID = p.graph.resolveAlias(img.compatibilityID)
 		idMap[img.id] = struct{}{}
-		idMap[img.compatibilityID] = struct{}{}
+		idMap[imgs[i].compatibilityID] = struct{}{}
 
-		if p.graph.Exists(img.compatibilityID) {
-			if _, err := p.graph.GenerateV1CompatibilityChain(img.compatibilityID); err != nil {
+		if p.graph.Exists(imgs[i].compatibilityID) {
+			if _, err := p.graph.GenerateV1CompatibilityChain(imgs[i].compatibilityID); err != nil {
 				logrus.Debugf("Migration v1Compatibility generation error: %v", err)
 				return
 			}
@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/digest"
 	"github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/registry/api/v2"
 	"github.com/sara-nl/docker-1.9.1/image"
 	"github.com/sara-nl/docker-1.9.1/pkg/progressreader"
 	"github.com/sara-nl/docker-1.9.1/pkg/streamformatter"
@@ -33,6 +36,7 @@ type v2Pusher struct {
 	config    *ImagePushConfig
 	sf        *streamformatter.StreamFormatter
 	repo      distribution.Repository
+	auth      *v2Auth
 
 	// layersPushed is the set of layers known to exist on the remote side.
 	// This avoids redundant queries when pushing multiple tags that
@@ -41,7 +45,7 @@ type v2Pusher struct {
 }
 
 func (p *v2Pusher) Push() (fallback bool, err error) {
-	p.repo, err = NewV2Repository(p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "push", "pull")
+	p.repo, p.auth, err = newV2Repository(p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "push", "pull")
 	if err != nil {
 		logrus.Debugf("Error getting v2 registry: %v", err)
 		return true, err
@@ -161,7 +165,7 @@ func (p *v2Pusher) pushV2Tag(tag string) error {
 				exists = true
 				out.Write(p.sf.FormatProgress(stringid.TruncateID(layer.ID), "Image already exists", nil))
 			case distribution.ErrBlobUnknown:
-				// nop
+				exists = p.mountLayer(layer.ID, dgst)
 			default:
 				out.Write(p.sf.FormatProgress(stringid.TruncateID(layer.ID), "Image push failed", nil))
 				return err
@@ -199,6 +203,9 @@ func (p *v2Pusher) pushV2Tag(tag string) error {
 
 		layersSeen[layer.ID] = true
 		p.layersPushed[dgst] = true
+		if err := p.graph.AddBlobSource(dgst, p.repoInfo.Index.Name, p.repo.Name()); err != nil {
+			logrus.Warnf("Failed to record that layer %s was pushed to %s: %v", layer.ID, p.repo.Name(), err)
+		}
 	}
 
 	// Fix parent chain if necessary
@@ -389,3 +396,76 @@ func (p *v2Pusher) pushV2Image(bs distribution.BlobService, img *image.Image) (d
 
 	return dgst, nil
 }
+
+// mountLayer tries to mount the blob dgst of the layer of the image id in
+// the repository from the other repositories of the registry the blob was
+// pushed to or pulled from, and returns whether it succeeded. Failures are not
+// fatal, the layer is then pushed as usual.
+func (p *v2Pusher) mountLayer(id string, dgst digest.Digest) bool {
+	sources, err := p.graph.GetBlobSources(dgst, p.repoInfo.Index.Name)
+	if err != nil {
+		logrus.Debugf("Error getting the sources of blob %s: %v", dgst, err)
+		return false
+	}
+	for _, from := range sources {
+		if from == p.repo.Name() {
+			continue
+		}
+		mounted, err := p.mountBlob(dgst, from)
+		if err != nil {
+			logrus.Debugf("Error mounting blob %s from %s: %v", dgst, from, err)
+			continue
+		}
+		if mounted {
+			p.config.OutStream.Write(p.sf.FormatProgress(stringid.TruncateID(id), fmt.Sprintf("Mounted from %s", from), nil))
+			return true
+		}
+	}
+	return false
+}
+
+// mountBlob asks the registry to mount the blob dgst of the repository from
+// in the repository being pushed, without uploading it. It returns false if
+// the registry did not mount the blob, because it does not support
+// cross-repository mounts, the blob does not exist in from, or the user may
+// not pull from it.
+func (p *v2Pusher) mountBlob(dgst digest.Digest, from string) (bool, error) {
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL)
+	if err != nil {
+		return false, err
+	}
+	mountURL, err := ub.BuildBlobUploadURL(p.repo.Name(), url.Values{
+		"mount": {dgst.String()},
+		"from":  {from},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{Transport: p.auth.Transport(registry.RepositoryScope(p.repo.Name(), "push", "pull"), registry.RepositoryScope(from, "pull"))}
+	res, err := client.Post(mountURL, "", nil)
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusCreated:
+		return true, nil
+	case http.StatusAccepted:
+		// The registry started a regular upload instead, which is
+		// not needed.
+		if location := res.Header.Get("Location"); location != "" {
+			if uploadURL, err := res.Request.URL.Parse(location); err == nil {
+				req, err := http.NewRequest("DELETE", uploadURL.String(), nil)
+				if err == nil {
+					if res, err := client.Do(req); err == nil {
+						res.Body.Close()
+					}
+				}
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected status %s", res.Status)
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/sara-nl/docker-1.9.1/registry"
+)
+
+func TestMountBlob(t *testing.T) {
+	dgst, err := digest.FromBytes([]byte("layer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "POST" && r.URL.Path == "/v2/foo/app/blobs/uploads/":
+			if r.URL.Query().Get("mount") != dgst.String() {
+				t.Errorf("Unexpected mount %q", r.URL.Query().Get("mount"))
+			}
+			if r.URL.Query().Get("from") == "foo/base" {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			w.Header().Set("Location", "/v2/foo/app/blobs/uploads/1234")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "DELETE" && r.URL.Path == "/v2/foo/app/blobs/uploads/1234":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repoInfo := &registry.RepositoryInfo{
+		Index:         &registry.IndexInfo{Name: strings.TrimPrefix(server.URL, "http://")},
+		RemoteName:    "foo/app",
+		CanonicalName: strings.TrimPrefix(server.URL, "http://") + "/foo/app",
+	}
+	endpoint := registry.APIEndpoint{URL: server.URL, Version: registry.APIVersion2, TrimHostname: true}
+	repo, a, err := newV2Repository(repoInfo, endpoint, nil, nil, "push", "pull")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &v2Pusher{endpoint: endpoint, repoInfo: repoInfo, repo: repo, auth: a}
+
+	mounted, err := p.mountBlob(dgst, "foo/base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mounted {
+		t.Fatal("Expected the blob to be mounted from foo/base")
+	}
+
+	mounted, err = p.mountBlob(dgst, "foo/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounted {
+		t.Fatal("Expected the blob not to be mounted from foo/other")
+	}
+	if !deleted {
+		t.Fatal("Expected the upload started instead of the mount to be cancelled")
+	}
+}
@@ -1,36 +1,30 @@
 package graph
 
 import (
-	"errors"
-	"net"
 	"net/http"
-	"net/url"
-	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/digest"
 	"github.com/docker/distribution/manifest"
 	"github.com/docker/distribution/registry/client"
-	"github.com/docker/distribution/registry/client/auth"
-	"github.com/docker/distribution/registry/client/transport"
 	"github.com/sara-nl/docker-1.9.1/cliconfig"
 	"github.com/sara-nl/docker-1.9.1/registry"
 	"golang.org/x/net/context"
 )
 
-type dumbCredentialStore struct {
-	auth *cliconfig.AuthConfig
-}
-
-func (dcs dumbCredentialStore) Basic(*url.URL) (string, string) {
-	return dcs.auth.Username, dcs.auth.Password
-}
-
 // NewV2Repository returns a repository (v2 only). It creates a HTTP transport
 // providing timeout settings and authentication support, and also verifies the
 // remote API version.
 func NewV2Repository(repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint, metaHeaders http.Header, authConfig *cliconfig.AuthConfig, actions ...string) (distribution.Repository, error) {
+	repo, _, err := newV2Repository(repoInfo, endpoint, metaHeaders, authConfig, actions...)
+	return repo, err
+}
+
+// newV2Repository is like NewV2Repository, but also returns the
+// authentication state of the registry, from which the transport used by the
+// repository and transports with other scopes can be obtained.
+func newV2Repository(repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint, metaHeaders http.Header, authConfig *cliconfig.AuthConfig, actions ...string) (distribution.Repository, *v2Auth, error) {
 	ctx := context.Background()
 
 	repoName := repoInfo.CanonicalName
@@ -39,64 +33,22 @@ func NewV2Repository(repoInfo *registry.RepositoryInfo, endpoint registry.APIEnd
 		repoName = repoInfo.RemoteName
 	}
 
-	// TODO(dmcgowan): Call close idle connections when complete, use keep alive
-	base := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		Dial: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).Dial,
-		TLSHandshakeTimeout: 10 * time.Second,
-		TLSClientConfig:     endpoint.TLSConfig,
-		// TODO(dmcgowan): Call close idle connections when complete and use keep alive
-		DisableKeepAlives: true,
-	}
-
-	modifiers := registry.DockerHeaders(metaHeaders)
-	authTransport := transport.NewTransport(base, modifiers...)
-	pingClient := &http.Client{
-		Transport: authTransport,
-		Timeout:   15 * time.Second,
-	}
-	endpointStr := endpoint.URL + "/v2/"
-	req, err := http.NewRequest("GET", endpointStr, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := pingClient.Do(req)
+	v2auth, err := registry.NewV2Auth(endpoint, metaHeaders, authConfig)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	versions := auth.APIVersions(resp, endpoint.VersionHeader)
-	if endpoint.VersionHeader != "" && len(endpoint.Versions) > 0 {
-		var foundVersion bool
-		for _, version := range endpoint.Versions {
-			for _, pingVersion := range versions {
-				if version == pingVersion {
-					foundVersion = true
-				}
-			}
-		}
-		if !foundVersion {
-			return nil, errors.New("endpoint does not support v2 API")
-		}
-	}
-
-	challengeManager := auth.NewSimpleChallengeManager()
-	if err := challengeManager.AddResponse(resp); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	a := &v2Auth{V2Auth: v2auth}
+	a.transport = a.Transport(registry.RepositoryScope(repoName, actions...))
 
-	creds := dumbCredentialStore{auth: authConfig}
-	tokenHandler := auth.NewTokenHandler(authTransport, creds, repoName, actions...)
-	basicHandler := auth.NewBasicHandler(creds)
-	modifiers = append(modifiers, auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
-	tr := transport.NewTransport(base, modifiers...)
+	repo, err := client.NewRepository(ctx, repoName, endpoint.URL, a.transport)
+	return repo, a, err
+}
 
-	return client.NewRepository(ctx, repoName, endpoint.URL, tr)
+// v2Auth is the authentication state of a v2 registry endpoint, with the
+// transport of the repository the endpoint was looked up for.
+type v2Auth struct {
+	*registry.V2Auth
+	transport http.RoundTripper
 }
 
 func digestFromManifest(m *manifest.SignedManifest, localName string) (digest.Digest, int, error) {
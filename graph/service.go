@@ -57,7 +57,7 @@ func (s *TagStore) Lookup(name string) (*types.ImageInspect, error) {
 
 	imageInspect.GraphDriver.Name = s.graph.driver.String()
 
-	graphDriverData, err := s.graph.driver.GetMetadata(image.ID)
+	graphDriverData, err := s.graph.driver.GetMetadata(s.graph.LayerID(image.ID))
 	if err != nil {
 		return nil, err
 	}
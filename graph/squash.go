@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/sara-nl/docker-1.9.1/autogen/dockerversion"
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+	"github.com/sara-nl/docker-1.9.1/pkg/chrootarchive"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringutils"
+)
+
+// Squash creates an image with the same configuration as img, in which the
+// layers of img on top of the image from are merged into a single layer. If
+// from is empty, all the layers of img are merged into a single base layer.
+//
+// The layers are applied one by one onto a scratch layer in the storage
+// driver, so that files deleted by a layer are removed the same way they are
+// when running a container, and the diff of the scratch layer becomes the
+// layer of the new image.
+func (graph *Graph) Squash(img *image.Image, from string) (*image.Image, error) {
+	if from != "" {
+		fromImg, err := graph.Get(from)
+		if err != nil {
+			return nil, err
+		}
+		from = fromImg.ID
+	}
+
+	// Collect the layers to merge, from the bottom up.
+	var layers []*image.Image
+	for layer := img; layer != nil && layer.ID != from; {
+		layers = append([]*image.Image{layer}, layers...)
+		parent, err := graph.GetParent(layer)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil && from != "" {
+			return nil, fmt.Errorf("image %s is not an ancestor of image %s", stringid.TruncateID(from), stringid.TruncateID(img.ID))
+		}
+		layer = parent
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("image %s has no layers on top of itself to squash", stringid.TruncateID(img.ID))
+	}
+
+	fromLayer := graph.LayerID(from)
+	scratch := "squash-" + stringid.GenerateRandomID()
+	if err := graph.driver.Create(scratch, fromLayer); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := graph.driver.Remove(scratch); err != nil {
+			logrus.Warnf("failed to remove squash layer %s: %v", scratch, err)
+		}
+	}()
+
+	if err := graph.applyLayers(scratch, layers); err != nil {
+		return nil, err
+	}
+
+	layerData, err := graph.driver.Diff(scratch, fromLayer)
+	if err != nil {
+		return nil, err
+	}
+	defer layerData.Close()
+
+	squashed := &image.Image{
+		Parent:          from,
+		Comment:         fmt.Sprintf("Squashed %d layers of image %s", len(layers), stringid.TruncateID(img.ID)),
+		Created:         time.Now().UTC(),
+		Container:       img.Container,
+		ContainerConfig: img.ContainerConfig,
+		DockerVersion:   dockerversion.VERSION,
+		Author:          img.Author,
+		Config:          img.Config,
+		Architecture:    img.Architecture,
+		OS:              img.OS,
+	}
+	squashed.ContainerConfig.Cmd = stringutils.NewStrSlice("/bin/sh", "-c", "#(nop) "+squashed.Comment)
+
+	graph.imagesMutex.Lock()
+	id, err := graph.register(v1Descriptor{squashed}, layerData)
+	graph.imagesMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return graph.Get(id)
+}
+
+// applyLayers applies the layers of the images, in order, onto the mounted
+// driver layer id.
+func (graph *Graph) applyLayers(id string, layers []*image.Image) error {
+	dir, err := graph.driver.Get(id, "")
+	if err != nil {
+		return err
+	}
+	defer graph.driver.Put(id)
+
+	options := &archive.TarOptions{
+		UIDMaps: graph.uidMaps,
+		GIDMaps: graph.gidMaps,
+	}
+	for _, layer := range layers {
+		if err := graph.applyLayer(dir, layer, options); err != nil {
+			return fmt.Errorf("failed to apply layer %s: %v", stringid.TruncateID(layer.ID), err)
+		}
+	}
+	return nil
+}
+
+func (graph *Graph) applyLayer(dir string, layer *image.Image, options *archive.TarOptions) error {
+	arch, err := graph.TarLayer(layer)
+	if err != nil {
+		return err
+	}
+	defer arch.Close()
+	_, err = chrootarchive.ApplyUncompressedLayer(dir, arch, options)
+	return err
+}
+
+// Squash merges the layers of the image name on top of the image from into
+// a single layer, and tags the resulting image as repo:tag if repo is not
+// empty. If from is empty, all the layers of the image are merged.
+func (s *TagStore) Squash(name, from, repo, tag string) (*image.Image, error) {
+	img, err := s.LookupImage(name)
+	if err != nil {
+		return nil, err
+	}
+	if from != "" {
+		fromImg, err := s.LookupImage(from)
+		if err != nil {
+			return nil, err
+		}
+		from = fromImg.ID
+	}
+
+	squashed, err := s.graph.Squash(img, from)
+	if err != nil {
+		return nil, err
+	}
+	if repo != "" {
+		if err := s.Tag(repo, tag, squashed.ID, true); err != nil {
+			return squashed, err
+		}
+	}
+	s.eventsService.Log("squash", squashed.ID, "")
+	return squashed, nil
+}
@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sara-nl/docker-1.9.1/image"
+	"github.com/sara-nl/docker-1.9.1/pkg/archive"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+	"github.com/sara-nl/docker-1.9.1/runconfig"
+)
+
+// layerTar returns a layer adding the given files, and removing the files
+// given as whiteouts.
+func layerTar(files []string, whiteouts []string) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, name := range files {
+		hdr := &tar.Header{Name: name, Size: int64(len(name)), Mode: 0600, Uid: os.Getuid(), Gid: os.Getgid()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		tw.Write([]byte(name))
+	}
+	for _, name := range whiteouts {
+		hdr := &tar.Header{Name: filepath.Join(filepath.Dir(name), archive.WhiteoutPrefix+filepath.Base(name)), Mode: 0600, Uid: os.Getuid(), Gid: os.Getgid()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+	}
+	tw.Close()
+	return buf, nil
+}
+
+func registerLayer(graph *Graph, parent string, files, whiteouts []string, t *testing.T) *image.Image {
+	layer, err := layerTar(files, whiteouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := &image.Image{
+		ID:      stringid.GenerateNonCryptoID(),
+		Parent:  parent,
+		Created: time.Now(),
+		Config:  &runconfig.Config{Env: []string{"FOO=bar"}},
+	}
+	if err := graph.Register(v1Descriptor{img}, layer); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := graph.Get(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+// layerFiles returns the names of the files in the layer of img.
+func layerFiles(graph *Graph, img *image.Image, t *testing.T) map[string]bool {
+	arch, err := graph.TarLayer(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer arch.Close()
+	files := make(map[string]bool)
+	tr := tar.NewReader(arch)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[filepath.Clean("/"+hdr.Name)] = true
+	}
+	return files
+}
+
+func TestSquash(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+
+	base := registerLayer(graph, "", []string{"base"}, nil, t)
+	middle := registerLayer(graph, base.ID, []string{"a", "b"}, nil, t)
+	top := registerLayer(graph, middle.ID, []string{"c"}, []string{"a"}, t)
+
+	squashed, err := graph.Squash(top, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if squashed.Parent != "" {
+		t.Fatalf("Expected a base image, got parent %s", squashed.Parent)
+	}
+	if squashed.Config == nil || len(squashed.Config.Env) != 1 || squashed.Config.Env[0] != "FOO=bar" {
+		t.Fatalf("Expected the configuration to be kept, got %+v", squashed.Config)
+	}
+	files := layerFiles(graph, squashed, t)
+	for _, name := range []string{"/base", "/b", "/c"} {
+		if !files[name] {
+			t.Fatalf("Expected %s in the squashed layer, got %v", name, files)
+		}
+	}
+	if files["/a"] || files["/"+archive.WhiteoutPrefix+"a"] {
+		t.Fatalf("Expected removed file a to be left out of the squashed layer, got %v", files)
+	}
+
+	squashed, err = graph.Squash(top, base.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if squashed.Parent != base.ID {
+		t.Fatalf("Expected parent %s, got %s", base.ID, squashed.Parent)
+	}
+	files = layerFiles(graph, squashed, t)
+	if files["/base"] || !files["/b"] || !files["/c"] {
+		t.Fatalf("Expected only the files added on top of the base image, got %v", files)
+	}
+
+	if _, err := graph.Squash(middle, top.ID); err == nil {
+		t.Fatal("Expected an error squashing from an image that is not a parent")
+	}
+	if _, err := graph.Squash(top, top.ID); err == nil {
+		t.Fatal("Expected an error squashing an image onto itself")
+	}
+}
@@ -36,6 +36,10 @@ type TagStore struct {
 	// Repositories is a map of repositories, indexed by name.
 	Repositories map[string]Repository
 	trustKey     libtrust.PrivateKey
+	// trustedKeys are the keys trusted to sign image bundles, besides
+	// trustKey.
+	trustedKeys           []libtrust.PublicKey
+	requireTrustedBundles bool
 	sync.Mutex
 	// FIXME: move push/pull-related fields
 	// to a helper type
@@ -43,6 +47,7 @@ type TagStore struct {
 	pushingPool     map[string]*broadcaster.Buffered
 	registryService *registry.Service
 	eventsService   *events.Events
+	downloads       *downloadManager
 }
 
 // Repository maps tags to image IDs.
@@ -78,6 +83,15 @@ type TagStoreConfig struct {
 	Registry *registry.Service
 	// Events is the events service to use for logging.
 	Events *events.Events
+	// MaxConcurrentDownloads is the maximum number of layers downloaded at
+	// the same time by all pulls. Zero means no limit.
+	MaxConcurrentDownloads int
+	// TrustedKeys are the public keys trusted to sign the manifests of
+	// image bundles, besides Key.
+	TrustedKeys []libtrust.PublicKey
+	// RequireTrustedBundles rejects image bundles whose manifests are not
+	// signed by a trusted key.
+	RequireTrustedBundles bool
 }
 
 // NewTagStore creates a new TagStore at specified path, using the parameters
@@ -89,14 +103,17 @@ func NewTagStore(path string, cfg *TagStoreConfig) (*TagStore, error) {
 	}
 
 	store := &TagStore{
-		path:            abspath,
-		graph:           cfg.Graph,
-		trustKey:        cfg.Key,
-		Repositories:    make(map[string]Repository),
-		pullingPool:     make(map[string]*broadcaster.Buffered),
-		pushingPool:     make(map[string]*broadcaster.Buffered),
-		registryService: cfg.Registry,
-		eventsService:   cfg.Events,
+		path:                  abspath,
+		graph:                 cfg.Graph,
+		trustKey:              cfg.Key,
+		trustedKeys:           cfg.TrustedKeys,
+		requireTrustedBundles: cfg.RequireTrustedBundles,
+		Repositories:          make(map[string]Repository),
+		pullingPool:           make(map[string]*broadcaster.Buffered),
+		pushingPool:           make(map[string]*broadcaster.Buffered),
+		registryService:       cfg.Registry,
+		eventsService:         cfg.Events,
+		downloads:             newDownloadManager(cfg.MaxConcurrentDownloads),
 	}
 	// Load the json file if it exists, otherwise create it.
 	if err := store.reload(); os.IsNotExist(err) {
@@ -105,6 +122,10 @@ func NewTagStore(path string, cfg *TagStoreConfig) (*TagStore, error) {
 		}
 	} else if err != nil {
 		return nil, err
+	} else if store.migrateIDs() {
+		if err := store.save(); err != nil {
+			return nil, err
+		}
 	}
 	return store, nil
 }
@@ -403,6 +424,15 @@ func (store *TagStore) GetImage(repoName, refOrID string) (*image.Image, error)
 				return store.graph.Get(revision)
 			}
 		}
+		// The ID may be the one the image had before it got its
+		// content-addressable ID.
+		if id, err := store.graph.resolveID(refOrID); err == nil {
+			for _, revision := range repo {
+				if revision == id {
+					return store.graph.Get(revision)
+				}
+			}
+		}
 	}
 
 	return nil, nil
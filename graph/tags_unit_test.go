@@ -98,6 +98,15 @@ func mkTestTagStore(root string, t *testing.T) *TagStore {
 	return store
 }
 
+// mustResolveID returns the ID the image registered as id is stored under.
+func mustResolveID(store *TagStore, id string, t *testing.T) string {
+	img, err := store.graph.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img.ID
+}
+
 func TestLookupImage(t *testing.T) {
 	tmp, err := utils.TestDirectory("")
 	if err != nil {
@@ -107,11 +116,20 @@ func TestLookupImage(t *testing.T) {
 	store := mkTestTagStore(tmp, t)
 	defer store.graph.driver.Cleanup()
 
+	// The images are registered under content-addressable IDs, their v1 IDs
+	// remain usable as aliases.
+	officialImageID := mustResolveID(store, testOfficialImageID, t)
+	privateImageID := mustResolveID(store, testPrivateImageID, t)
+
 	officialLookups := []string{
 		testOfficialImageID,
 		testOfficialImageIDShort,
 		testOfficialImageName + ":" + testOfficialImageID,
 		testOfficialImageName + ":" + testOfficialImageIDShort,
+		officialImageID,
+		officialImageID[:12],
+		testOfficialImageName + ":" + officialImageID,
+		testOfficialImageName + ":" + officialImageID[:12],
 		testOfficialImageName,
 		testOfficialImageName + ":" + tags.DefaultTag,
 		"docker.io/" + testOfficialImageName,
@@ -131,6 +149,10 @@ func TestLookupImage(t *testing.T) {
 		testPrivateImageIDShort,
 		testPrivateImageName + ":" + testPrivateImageID,
 		testPrivateImageName + ":" + testPrivateImageIDShort,
+		privateImageID,
+		privateImageID[:12],
+		testPrivateImageName + ":" + privateImageID,
+		testPrivateImageName + ":" + privateImageID[:12],
 		testPrivateImageName,
 		testPrivateImageName + ":" + tags.DefaultTag,
 	}
@@ -149,8 +171,8 @@ func TestLookupImage(t *testing.T) {
 			t.Errorf("Error looking up %s: %s", name, err)
 		} else if img == nil {
 			t.Errorf("Expected 1 image, none found: %s", name)
-		} else if img.ID != testOfficialImageID {
-			t.Errorf("Expected ID '%s' found '%s'", testOfficialImageID, img.ID)
+		} else if img.ID != officialImageID {
+			t.Errorf("Expected ID '%s' found '%s'", officialImageID, img.ID)
 		}
 	}
 
@@ -159,8 +181,8 @@ func TestLookupImage(t *testing.T) {
 			t.Errorf("Error looking up %s: %s", name, err)
 		} else if img == nil {
 			t.Errorf("Expected 1 image, none found: %s", name)
-		} else if img.ID != testPrivateImageID {
-			t.Errorf("Expected ID '%s' found '%s'", testPrivateImageID, img.ID)
+		} else if img.ID != privateImageID {
+			t.Errorf("Expected ID '%s' found '%s'", privateImageID, img.ID)
 		}
 	}
 
@@ -177,8 +199,8 @@ func TestLookupImage(t *testing.T) {
 			t.Errorf("Error looking up %s: %s", name, err)
 		} else if img == nil {
 			t.Errorf("Expected 1 image, none found: %s", name)
-		} else if img.ID != testPrivateImageID {
-			t.Errorf("Expected ID '%s' found '%s'", testPrivateImageID, img.ID)
+		} else if img.ID != privateImageID {
+			t.Errorf("Expected ID '%s' found '%s'", privateImageID, img.ID)
 		}
 	}
 }
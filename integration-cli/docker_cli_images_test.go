@@ -245,3 +245,52 @@ func (s *DockerSuite) TestImagesEnsureImagesFromScratchShown(c *check.C) {
 		c.Fatalf("images should contain images built from scratch (e.g. %s), got %s", id[:12], out)
 	}
 }
+
+// imageUseCount returns the USES column of `docker images --usage` for the
+// image.
+func imageUseCount(c *check.C, name string) string {
+	out, _ := dockerCmd(c, "images", "--usage", name)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	c.Assert(lines, checker.HasLen, 2, check.Commentf("expected a single image in %q", out))
+	fields := strings.Fields(lines[1])
+	return fields[len(fields)-1]
+}
+
+func (s *DockerSuite) TestImagesUsageCountsStarts(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "usagecount"
+	_, err := buildImage(name,
+		`FROM busybox
+		LABEL usage=count`, true)
+	c.Assert(err, check.IsNil)
+	c.Assert(imageUseCount(c, name), checker.Equals, "0")
+
+	// a run creates and starts a container, and counts once
+	dockerCmd(c, "run", "--rm", name, "true")
+	c.Assert(imageUseCount(c, name), checker.Equals, "1")
+
+	out, _ := dockerCmd(c, "create", name, "true")
+	c.Assert(imageUseCount(c, name), checker.Equals, "1")
+	dockerCmd(c, "start", "-a", strings.TrimSpace(out))
+	c.Assert(imageUseCount(c, name), checker.Equals, "2")
+
+	// restarts count too
+	dockerCmd(c, "restart", strings.TrimSpace(out))
+	c.Assert(imageUseCount(c, name), checker.Equals, "3")
+}
+
+func (s *DockerSuite) TestImagesUnusedSinceSkipsImagesOfContainers(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "unusedsincecontainer"
+	_, err := buildImage(name,
+		`FROM busybox
+		LABEL unused=since`, true)
+	c.Assert(err, check.IsNil)
+
+	// a container started from the image long ago still uses it
+	dockerCmd(c, "run", "-d", name, "top")
+	out, _ := dockerCmd(c, "images", "-q", "--filter", "unused-since=0s")
+	id, err := getIDByName(name)
+	c.Assert(err, check.IsNil)
+	c.Assert(out, checker.Not(checker.Contains), id[:12])
+}
@@ -288,26 +288,30 @@ func TestValidatePath(t *testing.T) {
 		"/path:rw",
 		"/path:ro",
 		"/rw:rw",
+		"name:/containerPath:rw,ceph",
+		"name:/containerPath:driver=iscsi,ro",
 	}
 	invalid := map[string]string{
-		"":                "bad format for path: ",
-		"./":              "./ is not an absolute path",
-		"../":             "../ is not an absolute path",
-		"/:../":           "../ is not an absolute path",
-		"/:path":          "path is not an absolute path",
-		":":               "bad format for path: :",
-		"/tmp:":           " is not an absolute path",
-		":test":           "bad format for path: :test",
-		":/test":          "bad format for path: :/test",
-		"tmp:":            " is not an absolute path",
-		":test:":          "bad format for path: :test:",
-		"::":              "bad format for path: ::",
-		":::":             "bad format for path: :::",
-		"/tmp:::":         "bad format for path: /tmp:::",
-		":/tmp::":         "bad format for path: :/tmp::",
-		"path:ro":         "path is not an absolute path",
-		"/path:/path:sw":  "bad mode specified: sw",
-		"/path:/path:rwz": "bad mode specified: rwz",
+		"":                    "bad format for path: ",
+		"./":                  "./ is not an absolute path",
+		"../":                 "../ is not an absolute path",
+		"/:../":               "../ is not an absolute path",
+		"/:path":              "path is not an absolute path",
+		":":                   "bad format for path: :",
+		"/tmp:":               " is not an absolute path",
+		":test":               "bad format for path: :test",
+		":/test":              "bad format for path: :/test",
+		"tmp:":                " is not an absolute path",
+		":test:":              "bad format for path: :test:",
+		"::":                  "bad format for path: ::",
+		":::":                 "bad format for path: :::",
+		"/tmp:::":             "bad format for path: /tmp:::",
+		":/tmp::":             "bad format for path: :/tmp::",
+		"path:ro":             "path is not an absolute path",
+		"/path:/path:sw":      "bad mode specified: sw",
+		"/path:/path:rwz":     "bad mode specified: rwz",
+		"name:/path:driver=":  "bad mode specified: driver=",
+		"name:/path:ceph,nfs": "bad mode specified: ceph,nfs",
 	}
 
 	for _, path := range valid {
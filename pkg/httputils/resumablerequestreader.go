@@ -32,6 +32,13 @@ func ResumableRequestReaderWithInitialResponse(c *http.Client, r *http.Request,
 	return &resumableRequestReader{client: c, request: r, maxFailures: maxfail, totalSize: totalsize, currentResponse: initialResponse}
 }
 
+// ResumableRequestReaderWithInitialResponseAt makes it possible to resume
+// reading the body of an already initiated request for the content starting
+// at offset, such as the response to a request with a Range header.
+func ResumableRequestReaderWithInitialResponseAt(c *http.Client, r *http.Request, maxfail uint32, totalsize, offset int64, initialResponse *http.Response) io.ReadCloser {
+	return &resumableRequestReader{client: c, request: r, maxFailures: maxfail, totalSize: totalsize, lastRange: offset, currentResponse: initialResponse}
+}
+
 func (r *resumableRequestReader) Read(p []byte) (n int, err error) {
 	if r.client == nil || r.request == nil {
 		return 0, fmt.Errorf("client and request can't be nil\n")
@@ -305,3 +305,54 @@ func TestResumableRequestReaderWithInitialResponse(t *testing.T) {
 		t.Errorf("resstr != srvtxt")
 	}
 }
+
+func TestResumableRequestReaderWithInitialResponseAt(t *testing.T) {
+	srvtxt := "some response text data"
+	offset := 5
+
+	var ranges []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		var start int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(srvtxt)-start))
+		w.WriteHeader(http.StatusPartialContent)
+		if len(ranges) == 1 {
+			// drop the connection after a few bytes
+			w.Write([]byte(srvtxt[start : start+5]))
+			w.(http.Flusher).Flush()
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte(srvtxt[start:]))
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resreq := ResumableRequestReaderWithInitialResponseAt(client, req, 5, int64(len(srvtxt)), int64(offset), res)
+	defer resreq.Close()
+
+	data, err := ioutil.ReadAll(resreq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != srvtxt[offset:] {
+		t.Fatalf("Expected %q, got %q", srvtxt[offset:], data)
+	}
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[1], fmt.Sprintf("bytes=%d-", offset+5)) {
+		t.Fatalf("Expected the read to resume at byte %d, got ranges %q", offset+5, ranges)
+	}
+}
@@ -23,12 +23,21 @@ const (
 type remoteError struct {
 	method string
 	err    string
+	// statusCode is the HTTP status of the response of the plugin, if any
+	statusCode int
 }
 
 func (e *remoteError) Error() string {
 	return fmt.Sprintf("Plugin Error: %s, %s", e.err, e.method)
 }
 
+// IsNotImplemented returns whether the error is the one of a call to a
+// method the plugin doesn't implement.
+func IsNotImplemented(err error) bool {
+	rerr, ok := err.(*remoteError)
+	return ok && (rerr.statusCode == http.StatusNotFound || rerr.statusCode == http.StatusNotImplemented)
+}
+
 // NewClient creates a new plugin client (http).
 func NewClient(addr string, tlsConfig tlsconfig.Options) (*Client, error) {
 	tr := &http.Transport{}
@@ -59,11 +68,16 @@ type Client struct {
 // Call calls the specified method with the specified arguments for the plugin.
 // It will retry for 30 seconds if a failure occurs when calling.
 func (c *Client) Call(serviceMethod string, args interface{}, ret interface{}) error {
+	return c.call(serviceMethod, args, ret, true)
+}
+
+// call is like Call, but only retries if retry is set.
+func (c *Client) call(serviceMethod string, args interface{}, ret interface{}, retry bool) error {
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(args); err != nil {
 		return err
 	}
-	body, err := c.callWithRetry(serviceMethod, &buf, true)
+	body, err := c.callWithRetry(serviceMethod, &buf, retry)
 	if err != nil {
 		return err
 	}
@@ -129,9 +143,9 @@ func (c *Client) callWithRetry(serviceMethod string, data io.Reader, retry bool)
 		if resp.StatusCode != http.StatusOK {
 			remoteErr, err := ioutil.ReadAll(resp.Body)
 			if err != nil {
-				return nil, &remoteError{err.Error(), serviceMethod}
+				return nil, &remoteError{err.Error(), serviceMethod, resp.StatusCode}
 			}
-			return nil, &remoteError{string(remoteErr), serviceMethod}
+			return nil, &remoteError{string(remoteErr), serviceMethod, resp.StatusCode}
 		}
 		return resp.Body, nil
 	}
@@ -104,3 +104,36 @@ func pluginPaths(base, name, ext string) []string {
 		filepath.Join(base, name, name+ext),
 	}
 }
+
+// Scan returns the names of all the plugins found in the plugin
+// directories.
+func Scan() ([]string, error) {
+	var names []string
+	if err := filepath.Walk(socketsPath, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if fi.Mode()&os.ModeSocket != 0 {
+			names = append(names, strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name())))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, path := range specsPaths {
+		if err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return nil
+			}
+			switch filepath.Ext(fi.Name()) {
+			case ".spec", ".json":
+				names = append(names, strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name())))
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
@@ -32,6 +32,15 @@ func isErr(t string) bool {
 	}
 }
 
+// title returns the exported name of an argument, keeping the Go
+// convention for initialisms such as ID.
+func title(s string) string {
+	if strings.ToLower(s) == "id" {
+		return "ID"
+	}
+	return strings.Title(s)
+}
+
 // Need to use this helper due to issues with go-vet
 func buildTag(s string) string {
 	return "+build " + s
@@ -41,8 +50,7 @@ var templFuncs = template.FuncMap{
 	"printArgs":   printArgs,
 	"marshalType": marshalType,
 	"isErr":       isErr,
-	"lower":       strings.ToLower,
-	"title":       strings.Title,
+	"title":       title,
 	"tag":         buildTag,
 }
 
@@ -80,16 +88,16 @@ type {{ .InterfaceType }}Proxy struct {
 			ret {{ $.InterfaceType }}Proxy{{ .Name }}Response
 		)
 		{{ range .Args }}
-			req.{{ title .Name }} = {{ lower .Name }} {{ end }}
+			req.{{ title .Name }} = {{ .Name }} {{ end }}
 		if err = pp.Call("{{ $.RPCName }}.{{ .Name }}", req, &ret); err != nil {
 			return
 		}
 		{{ range $r := .Returns }}
 			{{ if isErr .ArgType }}
 				if ret.{{ title .Name }} != "" {
-					{{ lower .Name }} = errors.New(ret.{{ title .Name }})
+					{{ .Name }} = errors.New(ret.{{ title .Name }})
 				} {{ end }}
-			{{ if isErr .ArgType | not }} {{ lower .Name }} = ret.{{ title .Name }} {{ end }} {{ end }}
+			{{ if isErr .ArgType | not }} {{ .Name }} = ret.{{ title .Name }} {{ end }} {{ end }}
 
 		return
 	}
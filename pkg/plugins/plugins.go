@@ -78,13 +78,19 @@ func newLocalPlugin(name, addr string) *Plugin {
 }
 
 func (p *Plugin) activate() error {
+	return p.activateWithRetry(true)
+}
+
+// activateWithRetry activates the plugin, retrying to connect to it for a
+// while if retry is set.
+func (p *Plugin) activateWithRetry(retry bool) error {
 	p.activateOnce.Do(func() {
-		p.activatErr = p.activateWithLock()
+		p.activatErr = p.activateWithLock(retry)
 	})
 	return p.activatErr
 }
 
-func (p *Plugin) activateWithLock() error {
+func (p *Plugin) activateWithLock(retry bool) error {
 	c, err := NewClient(p.Addr, p.TLSConfig)
 	if err != nil {
 		return err
@@ -92,7 +98,7 @@ func (p *Plugin) activateWithLock() error {
 	p.Client = c
 
 	m := new(Manifest)
-	if err = p.Client.Call("Plugin.Activate", nil, m); err != nil {
+	if err = p.Client.call("Plugin.Activate", nil, m, retry); err != nil {
 		return err
 	}
 
@@ -139,7 +145,7 @@ func loadWithRetry(name string, retry bool) (*Plugin, error) {
 		storage.plugins[name] = pl
 		storage.Unlock()
 
-		err = pl.activate()
+		err = pl.activateWithRetry(retry)
 
 		if err != nil {
 			storage.Lock()
@@ -176,6 +182,63 @@ func Get(name, imp string) (*Plugin, error) {
 	return nil, ErrNotImplements
 }
 
+// GetAll returns all the plugins found in the plugin directories that
+// implement the requested implementation. The plugins are activated at
+// once, without waiting for the ones that are not running, which are
+// skipped.
+func GetAll(imp string) ([]*Plugin, error) {
+	names, err := Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		name string
+		pl   *Plugin
+		err  error
+	}
+	results := make(chan result, len(names))
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			storage.Lock()
+			pl, ok := storage.plugins[name]
+			storage.Unlock()
+			if ok {
+				results <- result{name, pl, pl.activate()}
+				return
+			}
+			pl, err := loadWithRetry(name, false)
+			results <- result{name, pl, err}
+		}(name)
+	}
+	wg.Wait()
+	close(results)
+
+	var out []*Plugin
+	for r := range results {
+		if r.err != nil {
+			logrus.Errorf("Error activating plugin %s: %v", r.name, r.err)
+			continue
+		}
+		for _, driver := range r.pl.Manifest.Implements {
+			if driver == imp {
+				out = append(out, r.pl)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
 // Handle adds the specified function to the extpointHandlers.
 func Handle(iface string, fn func(string, *Client)) {
 	extpointHandlers[iface] = fn
@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetAll(t *testing.T) {
+	tmpdir, unregister := setup(t)
+	defer unregister()
+	addr := setupRemotePluginServer()
+	defer teardownRemotePluginServer()
+
+	mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", versionMimetype)
+		w.Write([]byte(`{"Implements": ["VolumeDriver"]}`))
+	})
+
+	specs := map[string]string{
+		"running": addr,
+		// nothing listens on this port, the plugin is not running
+		"stopped": "tcp://127.0.0.1:1",
+	}
+	for name, spec := range specs {
+		if err := ioutil.WriteFile(filepath.Join(tmpdir, name+".spec"), []byte(spec), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() {
+		storage.Lock()
+		delete(storage.plugins, "running")
+		storage.Unlock()
+	}()
+
+	names, err := Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("Expected the 2 plugins to be found, got %v", names)
+	}
+
+	start := time.Now()
+	pls, err := GetAll("VolumeDriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pls) != 1 || pls[0].Name != "running" {
+		t.Fatalf("Expected only the running plugin, got %v", pls)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expected the plugin that is not running not to be waited for, took %v", elapsed)
+	}
+
+	if pls, err = GetAll("NetworkDriver"); err != nil || len(pls) != 0 {
+		t.Fatalf("Expected no network driver plugins, got %v (%v)", pls, err)
+	}
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/distribution/registry/client/transport"
+	"github.com/sara-nl/docker-1.9.1/cliconfig"
+)
+
+// V2Auth holds the authentication state of a v2 registry endpoint, from
+// which transports authorized for the resources of the registry are
+// obtained.
+type V2Auth struct {
+	base             http.RoundTripper
+	authTransport    http.RoundTripper
+	modifiers        []transport.RequestModifier
+	challengeManager auth.ChallengeManager
+	creds            auth.CredentialStore
+}
+
+// NewV2Auth pings the v2 endpoint to check that it supports the v2 API, and
+// to learn how to authenticate to it with authConfig.
+func NewV2Auth(endpoint APIEndpoint, metaHeaders http.Header, authConfig *cliconfig.AuthConfig) (*V2Auth, error) {
+	// TODO(dmcgowan): Call close idle connections when complete, use keep alive
+	base := NewTransport(endpoint.TLSConfig)
+	modifiers := DockerHeaders(metaHeaders)
+	authTransport := transport.NewTransport(base, modifiers...)
+	pingClient := &http.Client{
+		Transport: authTransport,
+		Timeout:   15 * time.Second,
+	}
+	resp, err := pingClient.Get(endpoint.URL + "/v2/")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if endpoint.VersionHeader != "" && len(endpoint.Versions) > 0 {
+		var foundVersion bool
+		for _, version := range endpoint.Versions {
+			for _, pingVersion := range auth.APIVersions(resp, endpoint.VersionHeader) {
+				if version == pingVersion {
+					foundVersion = true
+				}
+			}
+		}
+		if !foundVersion {
+			return nil, errors.New("endpoint does not support v2 API")
+		}
+	}
+
+	challengeManager := auth.NewSimpleChallengeManager()
+	if err := challengeManager.AddResponse(resp); err != nil {
+		return nil, err
+	}
+
+	return &V2Auth{
+		base:             base,
+		authTransport:    authTransport,
+		modifiers:        modifiers,
+		challengeManager: challengeManager,
+		creds:            dumbCredentialStore{auth: authConfig},
+	}, nil
+}
+
+// Transport returns a transport authorized for the actions of the given
+// scopes.
+func (a *V2Auth) Transport(scopes ...Scope) http.RoundTripper {
+	tokenHandler := NewTokenHandler(a.authTransport, a.creds, scopes...)
+	basicHandler := auth.NewBasicHandler(a.creds)
+	modifiers := append([]transport.RequestModifier{}, a.modifiers...)
+	modifiers = append(modifiers, auth.NewAuthorizer(a.challengeManager, tokenHandler, basicHandler))
+	return transport.NewTransport(a.base, modifiers...)
+}
+
+type dumbCredentialStore struct {
+	auth *cliconfig.AuthConfig
+}
+
+func (dcs dumbCredentialStore) Basic(*url.URL) (string, string) {
+	if dcs.auth == nil {
+		return "", ""
+	}
+	return dcs.auth.Username, dcs.auth.Password
+}
@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/sara-nl/docker-1.9.1/cliconfig"
+)
+
+// catalogPageSize is the number of entries requested per page of the catalog
+// and tag lists of v2 registries.
+const catalogPageSize = 100
+
+// catalogScope is the scope of the token for listing the catalog of a v2
+// registry.
+var catalogScope = Scope{Resource: "registry", Name: "catalog", Actions: []string{"*"}}
+
+// searchV2 searches the catalog of the v2 registry of index for repositories
+// whose name contains term. The registry has no search API, so the whole
+// catalog is listed, page by page, and filtered here.
+func (s *Service) searchV2(index *IndexInfo, term string, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+	endpoints, err := s.lookupV2RegistryEndpoints(index.Name)
+	if err != nil {
+		return nil, err
+	}
+	// Mirrors don't serve the catalog of the registry.
+	endpoints = withoutMirrors(endpoints)
+
+	term = strings.ToLower(term)
+	var lastErr error
+	for _, endpoint := range endpoints {
+		client, err := newV2Client(endpoint, http.Header(headers), authConfig, catalogScope)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ub, err := v2.NewURLBuilderFromString(endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+		catalogURL, err := ub.BuildCatalogURL()
+		if err != nil {
+			return nil, err
+		}
+
+		results := &SearchResults{Query: term}
+		err = getPaginated(client, catalogURL, func(page []byte) error {
+			var catalog struct {
+				Repositories []string `json:"repositories"`
+			}
+			if err := json.Unmarshal(page, &catalog); err != nil {
+				return err
+			}
+			for _, name := range catalog.Repositories {
+				if strings.Contains(strings.ToLower(name), term) {
+					results.Results = append(results.Results, SearchResult{Name: index.Name + "/" + name})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logrus.Debugf("Listing the catalog of %s failed: %v", endpoint.URL, err)
+			lastErr = err
+			continue
+		}
+		results.NumResults = len(results.Results)
+		return results, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no v2 endpoint for %s", index.Name)
+	}
+	return nil, lastErr
+}
+
+// Tags lists the tags of the repository name in its v2 registry, sorted by
+// name.
+func (s *Service) Tags(name string, authConfig *cliconfig.AuthConfig, headers map[string][]string) ([]string, error) {
+	repoInfo, err := s.ResolveRepository(name)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := s.LookupRegistryEndpoints(repoInfo.CanonicalName)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, endpoint := range endpoints {
+		if endpoint.Version != APIVersion2 {
+			continue
+		}
+		remoteName := repoInfo.CanonicalName
+		if endpoint.TrimHostname {
+			remoteName = repoInfo.RemoteName
+		}
+		client, err := newV2Client(endpoint, http.Header(headers), authConfig, RepositoryScope(remoteName, "pull"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ub, err := v2.NewURLBuilderFromString(endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+		tagsURL, err := ub.BuildTagsURL(remoteName)
+		if err != nil {
+			return nil, err
+		}
+
+		var tags []string
+		err = getPaginated(client, tagsURL, func(page []byte) error {
+			var tagList struct {
+				Tags []string `json:"tags"`
+			}
+			if err := json.Unmarshal(page, &tagList); err != nil {
+				return err
+			}
+			tags = append(tags, tagList.Tags...)
+			return nil
+		})
+		if err != nil {
+			logrus.Debugf("Listing the tags of %s on %s failed: %v", remoteName, endpoint.URL, err)
+			lastErr = err
+			continue
+		}
+		sort.Strings(tags)
+		return tags, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no v2 endpoint for %s", repoInfo.CanonicalName)
+	}
+	return nil, lastErr
+}
+
+// newV2Client returns a HTTP client for the v2 endpoint, authorized for the
+// actions of the given scopes.
+func newV2Client(endpoint APIEndpoint, metaHeaders http.Header, authConfig *cliconfig.AuthConfig, scopes ...Scope) (*http.Client, error) {
+	a, err := NewV2Auth(endpoint, metaHeaders, authConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: a.Transport(scopes...),
+		Timeout:   1 * time.Minute,
+	}, nil
+}
+
+// getPaginated gets the list at u page by page, following the Link headers
+// of the responses, and calls fn with the body of each page.
+func getPaginated(client *http.Client, u string, fn func(page []byte) error) error {
+	next, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	q := next.Query()
+	q.Set("n", strconv.Itoa(catalogPageSize))
+	next.RawQuery = q.Encode()
+
+	for {
+		resp, err := client.Get(next.String())
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("Unexpected status code %d listing %s", resp.StatusCode, next.Path)
+		}
+		var page json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+
+		link, err := nextLink(resp.Header.Get("Link"))
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			return nil
+		}
+		next = next.ResolveReference(link)
+	}
+}
+
+// nextLink parses the URL of the next page out of the Link header of a
+// paginated response, of the form `</v2/_catalog?last=b&n=100>; rel="next"`.
+// It returns nil if there is no next page.
+func nextLink(header string) (*url.URL, error) {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.Replace(strings.Replace(param, " ", "", -1), `"`, "", -1) == "rel=next" {
+				return url.Parse(target[1 : len(target)-1])
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// newTestV2Registry returns a v2 registry serving the catalog and tag lists
+// of repos, paginated like the v2 API.
+func newTestV2Registry(repos map[string][]string) *httptest.Server {
+	var names []string
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// paginate writes the page of entries after the last one of the
+	// request, with a Link header to the next page if there is one.
+	paginate := func(w http.ResponseWriter, r *http.Request, key string, entries []string) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		start := sort.SearchStrings(entries, r.URL.Query().Get("last"))
+		if last := r.URL.Query().Get("last"); start < len(entries) && entries[start] == last {
+			start++
+		}
+		end := len(entries)
+		if n > 0 && start+n < end {
+			end = start + n
+			next := url.Values{"n": {strconv.Itoa(n)}, "last": {entries[end-1]}}
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+		}
+		json.NewEncoder(w).Encode(map[string][]string{key: entries[start:end]})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(DefaultRegistryVersionHeader, "registry/2.0")
+		if r.URL.Path == "/v2/" {
+			return
+		}
+		if r.URL.Path == "/v2/_catalog" {
+			paginate(w, r, "repositories", names)
+			return
+		}
+		for name, tags := range repos {
+			if r.URL.Path == "/v2/"+name+"/tags/list" {
+				paginate(w, r, "tags", tags)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestSearchV2(t *testing.T) {
+	repos := map[string][]string{}
+	for i := 0; i < 2*catalogPageSize+10; i++ {
+		repos[fmt.Sprintf("team%d/app", i)] = nil
+	}
+	repos["team1/Database"] = nil
+	server := newTestV2Registry(repos)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	s := Service{Config: makeServiceConfig(nil, []string{u.Host})}
+
+	results, err := s.Search("base", u.Host, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.NumResults != 1 || results.Results[0].Name != u.Host+"/team1/Database" {
+		t.Fatalf("Expected %s/team1/Database, got %v", u.Host, results.Results)
+	}
+
+	// Search terms can also name the registry, and match across pages.
+	results, err = s.Search(u.Host+"/app", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.NumResults != 2*catalogPageSize+10 {
+		t.Fatalf("Expected %d results, got %d", 2*catalogPageSize+10, results.NumResults)
+	}
+}
+
+func TestTags(t *testing.T) {
+	var tags []string
+	for i := 0; i < catalogPageSize+1; i++ {
+		tags = append(tags, fmt.Sprintf("%03d", i))
+	}
+	server := newTestV2Registry(map[string][]string{"team/app": tags})
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	s := Service{Config: makeServiceConfig(nil, []string{u.Host})}
+
+	listed, err := s.Tags(u.Host+"/team/app", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(listed, tags) {
+		t.Fatalf("Expected %v, got %v", tags, listed)
+	}
+
+	if _, err := s.Tags(u.Host+"/team/missing", nil, nil); err == nil {
+		t.Fatal("Expected an error for a missing repository")
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                                      "",
+		`</v2/_catalog?last=b&n=2>; rel="next"`: "/v2/_catalog?last=b&n=2",
+		`<https://other/first>; rel="first", </v2/x/tags/list?last=1>; rel=next`:   "/v2/x/tags/list?last=1",
+		`<https://other/first>; rel="first", </v2/x/tags/list?last=1>; rel="prev"`: "",
+	} {
+		link, err := nextLink(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (link == nil && expected != "") || (link != nil && link.String() != expected) {
+			t.Fatalf("Expected %q for %q, got %v", expected, header, link)
+		}
+	}
+}
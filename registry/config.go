@@ -54,7 +54,7 @@ var (
 // the current process.
 func (options *Options) InstallFlags(cmd *flag.FlagSet, usageFn func(string) string) {
 	options.Mirrors = opts.NewListOpts(ValidateMirror)
-	cmd.Var(&options.Mirrors, []string{"-registry-mirror"}, usageFn("Preferred Docker registry mirror"))
+	cmd.Var(&options.Mirrors, []string{"-registry-mirror"}, usageFn("Preferred Docker registry mirror, or registry=mirror for a private registry"))
 	options.InsecureRegistries = opts.NewListOpts(ValidateIndexName)
 	cmd.Var(&options.InsecureRegistries, []string{"-insecure-registry"}, usageFn("Enable insecure registry communication"))
 	cmd.BoolVar(&V2Only, []string{"-disable-legacy-registry"}, false, "Do not contact legacy registries")
@@ -82,6 +82,9 @@ type ServiceConfig struct {
 	InsecureRegistryCIDRs []*netIPNet           `json:"InsecureRegistryCIDRs"`
 	IndexConfigs          map[string]*IndexInfo `json:"IndexConfigs"`
 	Mirrors               []string
+	// RegistryMirrors maps the names of private registries to the
+	// mirrors to try before them.
+	RegistryMirrors map[string][]string `json:"RegistryMirrors"`
 }
 
 // NewServiceConfig returns a new instance of ServiceConfig
@@ -103,9 +106,19 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 	config := &ServiceConfig{
 		InsecureRegistryCIDRs: make([]*netIPNet, 0),
 		IndexConfigs:          make(map[string]*IndexInfo, 0),
-		// Hack: Bypass setting the mirrors to IndexConfigs since they are going away
-		// and Mirrors are only for the official registry anyways.
-		Mirrors: options.Mirrors.GetAll(),
+		Mirrors:               make([]string, 0),
+		RegistryMirrors:       make(map[string][]string),
+	}
+	// Split --registry-mirror into mirrors of the official registry and
+	// registry-specific mirrors.
+	// Hack: Bypass setting the mirrors to IndexConfigs since they are going away.
+	for _, m := range options.Mirrors.GetAll() {
+		indexName, mirror := splitMirror(m)
+		if indexName == "" || indexName == IndexName {
+			config.Mirrors = append(config.Mirrors, mirror)
+		} else {
+			config.RegistryMirrors[indexName] = append(config.RegistryMirrors[indexName], mirror)
+		}
 	}
 	// Split --insecure-registry into CIDR and registry-specific settings.
 	for _, r := range options.InsecureRegistries.GetAll() {
@@ -118,7 +131,7 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 			// Assume `host:port` if not CIDR.
 			config.IndexConfigs[r] = &IndexInfo{
 				Name:     r,
-				Mirrors:  make([]string, 0),
+				Mirrors:  config.mirrorsOf(r),
 				Secure:   false,
 				Official: false,
 			}
@@ -185,8 +198,40 @@ func (config *ServiceConfig) isSecureIndex(indexName string) bool {
 	return true
 }
 
-// ValidateMirror validates an HTTP(S) registry mirror
+// mirrorsOf returns the mirrors configured for the private registry
+// indexName.
+func (config *ServiceConfig) mirrorsOf(indexName string) []string {
+	mirrors := make([]string, 0)
+	return append(mirrors, config.RegistryMirrors[indexName]...)
+}
+
+// splitMirror breaks a validated --registry-mirror value into the name of
+// the registry it mirrors, which is empty for the official registry, and the
+// mirror URI.
+func splitMirror(val string) (string, string) {
+	if i := strings.Index(val, "="); i >= 0 {
+		return val[:i], val[i+1:]
+	}
+	return "", val
+}
+
+// ValidateMirror validates an HTTP(S) registry mirror. The mirror may be
+// prefixed with the name of the registry it mirrors, as in
+// `registry.example.com:5000=https://mirror.example.com`; otherwise it is a
+// mirror of the official registry.
 func ValidateMirror(val string) (string, error) {
+	var indexName string
+	if i := strings.Index(val, "="); i >= 0 {
+		var err error
+		if indexName, err = ValidateIndexName(val[:i]); err != nil {
+			return "", err
+		}
+		if indexName == "" || strings.Contains(indexName, "/") {
+			return "", fmt.Errorf("Invalid registry name %q for mirror %s", val[:i], val[i+1:])
+		}
+		val = val[i+1:]
+	}
+
 	uri, err := url.Parse(val)
 	if err != nil {
 		return "", fmt.Errorf("%s is not a valid URI", val)
@@ -200,7 +245,11 @@ func ValidateMirror(val string) (string, error) {
 		return "", fmt.Errorf("Unsupported path/query/fragment at end of the URI")
 	}
 
-	return fmt.Sprintf("%s://%s/", uri.Scheme, uri.Host), nil
+	mirror := fmt.Sprintf("%s://%s/", uri.Scheme, uri.Host)
+	if indexName != "" {
+		return indexName + "=" + mirror, nil
+	}
+	return mirror, nil
 }
 
 // ValidateIndexName validates an index name.
@@ -279,7 +328,7 @@ func (config *ServiceConfig) NewIndexInfo(indexName string) (*IndexInfo, error)
 	// Construct a non-configured index info.
 	index := &IndexInfo{
 		Name:     indexName,
-		Mirrors:  make([]string, 0),
+		Mirrors:  config.mirrorsOf(indexName),
 		Official: false,
 	}
 	index.Secure = config.isSecureIndex(indexName)
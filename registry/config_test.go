@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -16,6 +17,8 @@ func TestValidateMirror(t *testing.T) {
 		"https://127.0.0.1",
 		"http://127.0.0.1:5000",
 		"https://127.0.0.1:5000",
+		"registry.corp:5000=https://cache.site-a:5000",
+		"docker.io=https://mirror-1.com",
 	}
 
 	invalid := []string{
@@ -33,6 +36,11 @@ func TestValidateMirror(t *testing.T) {
 		"https://mirror-1.com/v1/",
 		"https://mirror-1.com/v1/#",
 		"https://mirror-1.com?q",
+		"=https://mirror-1.com",
+		"-registry.corp=https://mirror-1.com",
+		"registry.corp/repo=https://mirror-1.com",
+		"registry.corp=https://mirror-1.com/v1/",
+		"registry.corp=ftp://mirror-1.com",
 	}
 
 	for _, address := range valid {
@@ -47,3 +55,61 @@ func TestValidateMirror(t *testing.T) {
 		}
 	}
 }
+
+func TestRegistryMirrors(t *testing.T) {
+	config := makeServiceConfig([]string{
+		"http://mirror-1.com/",
+		"docker.io=http://mirror-2.com/",
+		"registry.corp:5000=https://cache.site-a:5000/",
+		"registry.corp:5000=https://cache.site-b:5000/",
+	}, nil)
+
+	expected := []string{"http://mirror-1.com/", "http://mirror-2.com/"}
+	if !reflect.DeepEqual(config.Mirrors, expected) {
+		t.Fatalf("Expected official mirrors %v, got %v", expected, config.Mirrors)
+	}
+
+	index, err := config.NewIndexInfo("registry.corp:5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"https://cache.site-a:5000/", "https://cache.site-b:5000/"}
+	if !reflect.DeepEqual(index.Mirrors, expected) {
+		t.Fatalf("Expected mirrors %v for registry.corp:5000, got %v", expected, index.Mirrors)
+	}
+
+	index, err = config.NewIndexInfo("registry.corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Mirrors) != 0 {
+		t.Fatalf("Expected no mirrors for registry.corp, got %v", index.Mirrors)
+	}
+}
+
+func TestLookupRegistryEndpoints(t *testing.T) {
+	s := &Service{Config: makeServiceConfig([]string{
+		"registry.corp:5000=https://cache.site-a:5000/",
+	}, []string{"registry.corp:5000"})}
+
+	pullEndpoints, err := s.LookupPullEndpoints("registry.corp:5000/team/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pullEndpoints) == 0 || !pullEndpoints[0].Mirror {
+		t.Fatalf("Expected the pull endpoints to start with the mirror, got %v", pullEndpoints)
+	}
+
+	endpoints, err := s.LookupRegistryEndpoints("registry.corp:5000/team/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != len(pullEndpoints)-1 {
+		t.Fatalf("Expected all endpoints but the mirror, got %v", endpoints)
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Mirror {
+			t.Fatalf("Expected no mirror endpoints, got %v", endpoint)
+		}
+	}
+}
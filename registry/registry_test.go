@@ -706,6 +706,45 @@ func TestMirrorEndpointLookup(t *testing.T) {
 	}
 }
 
+func TestRegistryMirrorEndpointLookup(t *testing.T) {
+	s := Service{Config: makeServiceConfig([]string{"registry.corp:5000=https://cache.site-a:5000/"}, nil)}
+	imageName := "registry.corp:5000/test/image"
+
+	pullAPIEndpoints, err := s.LookupPullEndpoints(imageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pullAPIEndpoints) < 2 {
+		t.Fatalf("Expected the mirror and the registry, got %v", pullAPIEndpoints)
+	}
+	if !pullAPIEndpoints[0].Mirror || pullAPIEndpoints[0].URL != "https://cache.site-a:5000/" {
+		t.Fatalf("Pull endpoints should start with the mirror, got %v", pullAPIEndpoints[0])
+	}
+	if pullAPIEndpoints[1].Mirror || pullAPIEndpoints[1].URL != "https://registry.corp:5000" {
+		t.Fatalf("Pull endpoints should fall back to the registry, got %v", pullAPIEndpoints[1])
+	}
+
+	pushAPIEndpoints, err := s.LookupPushEndpoints(imageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, endpoint := range pushAPIEndpoints {
+		if endpoint.Mirror {
+			t.Fatal("Push endpoint should not contain mirror")
+		}
+	}
+
+	pullAPIEndpoints, err = s.LookupPullEndpoints(IndexName + "/test/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, endpoint := range pullAPIEndpoints {
+		if endpoint.Mirror {
+			t.Fatal("Pull endpoints of the official registry should not contain the private mirror")
+		}
+	}
+}
+
 func TestPushRegistryTag(t *testing.T) {
 	r := spawnTestRegistrySession(t)
 	err := r.PushRegistryTag("foo42/bar", imageID, "stable", makeURL("/v1/"))
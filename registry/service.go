@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution/registry/client/auth"
 	"github.com/sara-nl/docker-1.9.1/cliconfig"
 )
@@ -51,15 +52,31 @@ func (s *Service) Auth(authConfig *cliconfig.AuthConfig) (string, error) {
 	return Login(authConfig, endpoint)
 }
 
-// Search queries the public registry for images matching the specified
-// search terms, and returns the results.
-func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+// Search queries the registry for images matching the specified search
+// terms, and returns the results. If registryName is not empty, the registry
+// to search is registryName, otherwise it is the index of term. Private
+// registries are searched through the catalog of their v2 API, falling back
+// to the v1 search API.
+func (s *Service) Search(term, registryName string, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+	if registryName != "" {
+		term = registryName + "/" + term
+	}
 
 	repoInfo, err := s.ResolveRepositoryBySearch(term)
 	if err != nil {
 		return nil, err
 	}
 
+	var v2Err error
+	if !repoInfo.Index.Official {
+		results, err := s.searchV2(repoInfo.Index, repoInfo.GetSearchTerm(), authConfig, headers)
+		if err == nil || V2Only {
+			return results, err
+		}
+		logrus.Debugf("Searching the catalog of %s failed, trying the v1 search: %v", repoInfo.Index.Name, err)
+		v2Err = err
+	}
+
 	// *TODO: Search multiple indexes.
 	endpoint, err := NewEndpoint(repoInfo.Index, http.Header(headers), APIVersionUnknown)
 	if err != nil {
@@ -70,7 +87,13 @@ func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers
 	if err != nil {
 		return nil, err
 	}
-	return r.SearchRepositories(repoInfo.GetSearchTerm())
+	results, err := r.SearchRepositories(repoInfo.GetSearchTerm())
+	if err != nil && v2Err != nil {
+		// The registry supports neither API, report the error of the
+		// preferred one.
+		return nil, v2Err
+	}
+	return results, err
 }
 
 // ResolveRepository splits a repository name into its components
@@ -131,15 +154,28 @@ func (s *Service) LookupPullEndpoints(repoName string) (endpoints []APIEndpoint,
 // It gives preference to v2 endpoints over v1, and HTTPS over plain HTTP.
 // Mirrors are not included.
 func (s *Service) LookupPushEndpoints(repoName string) (endpoints []APIEndpoint, err error) {
-	allEndpoints, err := s.lookupEndpoints(repoName)
-	if err == nil {
-		for _, endpoint := range allEndpoints {
-			if !endpoint.Mirror {
-				endpoints = append(endpoints, endpoint)
-			}
+	return s.LookupRegistryEndpoints(repoName)
+}
+
+// LookupRegistryEndpoints creates a list of the endpoints of the registry of
+// repoName itself, leaving out its mirrors, in order of preference. It gives
+// preference to v2 endpoints over v1, and HTTPS over plain HTTP.
+func (s *Service) LookupRegistryEndpoints(repoName string) (endpoints []APIEndpoint, err error) {
+	endpoints, err = s.lookupEndpoints(repoName)
+	if err != nil {
+		return nil, err
+	}
+	return withoutMirrors(endpoints), nil
+}
+
+// withoutMirrors returns the endpoints that are not mirrors.
+func withoutMirrors(allEndpoints []APIEndpoint) (endpoints []APIEndpoint) {
+	for _, endpoint := range allEndpoints {
+		if !endpoint.Mirror {
+			endpoints = append(endpoints, endpoint)
 		}
 	}
-	return endpoints, err
+	return endpoints
 }
 
 func (s *Service) lookupEndpoints(repoName string) (endpoints []APIEndpoint, err error) {
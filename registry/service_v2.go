@@ -13,19 +13,9 @@ func (s *Service) lookupV2Endpoints(repoName string) (endpoints []APIEndpoint, e
 	tlsConfig := &cfg
 	if strings.HasPrefix(repoName, DefaultNamespace+"/") {
 		// v2 mirrors
-		for _, mirror := range s.Config.Mirrors {
-			mirrorTLSConfig, err := s.tlsConfigForMirror(mirror)
-			if err != nil {
-				return nil, err
-			}
-			endpoints = append(endpoints, APIEndpoint{
-				URL: mirror,
-				// guess mirrors are v2
-				Version:      APIVersion2,
-				Mirror:       true,
-				TrimHostname: true,
-				TLSConfig:    mirrorTLSConfig,
-			})
+		endpoints, err = s.lookupV2Mirrors(s.Config.Mirrors)
+		if err != nil {
+			return nil, err
 		}
 		// v2 registry
 		endpoints = append(endpoints, APIEndpoint{
@@ -43,9 +33,19 @@ func (s *Service) lookupV2Endpoints(repoName string) (endpoints []APIEndpoint, e
 	if slashIndex <= 0 {
 		return nil, fmt.Errorf("invalid repo name: missing '/':  %s", repoName)
 	}
-	hostname := repoName[:slashIndex]
+	return s.lookupV2RegistryEndpoints(repoName[:slashIndex])
+}
+
+// lookupV2RegistryEndpoints returns the v2 endpoints of the private registry
+// hostname, mirrors first.
+func (s *Service) lookupV2RegistryEndpoints(hostname string) (endpoints []APIEndpoint, err error) {
+	tlsConfig, err := s.TLSConfig(hostname)
+	if err != nil {
+		return nil, err
+	}
 
-	tlsConfig, err = s.TLSConfig(hostname)
+	// v2 mirrors of the private registry
+	endpoints, err = s.lookupV2Mirrors(s.Config.RegistryMirrors[hostname])
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +56,7 @@ func (s *Service) lookupV2Endpoints(repoName string) (endpoints []APIEndpoint, e
 			Version: "2.0",
 		},
 	}
-	endpoints = []APIEndpoint{
+	endpoints = append(endpoints, []APIEndpoint{
 		{
 			URL:           "https://" + hostname,
 			Version:       APIVersion2,
@@ -65,7 +65,7 @@ func (s *Service) lookupV2Endpoints(repoName string) (endpoints []APIEndpoint, e
 			VersionHeader: DefaultRegistryVersionHeader,
 			Versions:      v2Versions,
 		},
-	}
+	}...)
 
 	if tlsConfig.InsecureSkipVerify {
 		endpoints = append(endpoints, APIEndpoint{
@@ -81,3 +81,22 @@ func (s *Service) lookupV2Endpoints(repoName string) (endpoints []APIEndpoint, e
 
 	return endpoints, nil
 }
+
+// lookupV2Mirrors returns the endpoints of the given registry mirrors.
+func (s *Service) lookupV2Mirrors(mirrors []string) (endpoints []APIEndpoint, err error) {
+	for _, mirror := range mirrors {
+		mirrorTLSConfig, err := s.tlsConfigForMirror(mirror)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, APIEndpoint{
+			URL: mirror,
+			// guess mirrors are v2
+			Version:      APIVersion2,
+			Mirror:       true,
+			TrimHostname: true,
+			TLSConfig:    mirrorTLSConfig,
+		})
+	}
+	return endpoints, nil
+}
@@ -7,6 +7,10 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/registry/client/auth"
 )
 
 type tokenResponse struct {
@@ -79,3 +83,128 @@ func getToken(username, password string, params map[string]string, registryEndpo
 
 	return tr.Token, nil
 }
+
+// Scope is a resource of a v2 registry, with the actions a token is
+// requested for on it.
+type Scope struct {
+	// Resource is the type of the resource, "repository" or "registry".
+	Resource string
+	// Name is the name of the resource, such as the name of a repository.
+	Name    string
+	Actions []string
+}
+
+// RepositoryScope returns the scope of the actions on the repository name.
+func RepositoryScope(name string, actions ...string) Scope {
+	return Scope{Resource: "repository", Name: name, Actions: actions}
+}
+
+// String returns the scope as it is requested from the token server, for
+// instance "repository:library/busybox:pull,push".
+func (s Scope) String() string {
+	return fmt.Sprintf("%s:%s:%s", s.Resource, s.Name, strings.Join(s.Actions, ","))
+}
+
+// tokenHandler authenticates requests to a v2 registry with a bearer token
+// requested for all of its scopes at once. Unlike the token handler of the
+// distribution client, it is not limited to a single repository.
+type tokenHandler struct {
+	transport http.RoundTripper
+	creds     auth.CredentialStore
+	scopes    []Scope
+
+	tokenLock       sync.Mutex
+	tokenCache      string
+	tokenExpiration time.Time
+}
+
+// NewTokenHandler returns a handler for the token authentication of v2
+// registries, requesting tokens for the given scopes.
+func NewTokenHandler(transport http.RoundTripper, creds auth.CredentialStore, scopes ...Scope) auth.AuthenticationHandler {
+	return &tokenHandler{
+		transport: transport,
+		creds:     creds,
+		scopes:    scopes,
+	}
+}
+
+func (th *tokenHandler) Scheme() string {
+	return "bearer"
+}
+
+func (th *tokenHandler) AuthorizeRequest(req *http.Request, params map[string]string) error {
+	th.tokenLock.Lock()
+	defer th.tokenLock.Unlock()
+	now := time.Now()
+	if now.After(th.tokenExpiration) {
+		token, err := th.fetchToken(params)
+		if err != nil {
+			return err
+		}
+		th.tokenCache = token
+		th.tokenExpiration = now.Add(time.Minute)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", th.tokenCache))
+	return nil
+}
+
+func (th *tokenHandler) fetchToken(params map[string]string) (string, error) {
+	realm, ok := params["realm"]
+	if !ok {
+		return "", errors.New("no realm specified for token auth challenge")
+	}
+
+	realmURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token auth challenge realm: %s", err)
+	}
+
+	req, err := http.NewRequest("GET", realmURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	reqParams := req.URL.Query()
+	if service := params["service"]; service != "" {
+		reqParams.Add("service", service)
+	}
+	for _, scope := range th.scopes {
+		reqParams.Add("scope", scope.String())
+	}
+
+	if th.creds != nil {
+		username, password := th.creds.Basic(realmURL)
+		if username != "" && password != "" {
+			reqParams.Add("account", username)
+			req.SetBasicAuth(username, password)
+		}
+	}
+
+	req.URL.RawQuery = reqParams.Encode()
+
+	client := &http.Client{
+		Transport: th.transport,
+		Timeout:   15 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token auth attempt for registry: %s request failed with status: %d %s", req.URL, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	tr := new(tokenResponse)
+	if err = json.NewDecoder(resp.Body).Decode(tr); err != nil {
+		return "", fmt.Errorf("unable to decode token response: %s", err)
+	}
+
+	if tr.Token == "" {
+		return "", errors.New("authorization server did not include a token in the response")
+	}
+
+	return tr.Token, nil
+}
@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type testCredentialStore struct{}
+
+func (testCredentialStore) Basic(*url.URL) (string, string) {
+	return "user", "secret"
+}
+
+func TestTokenHandlerScopes(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token": "t0k3n"}`))
+	}))
+	defer server.Close()
+
+	handler := NewTokenHandler(http.DefaultTransport, testCredentialStore{},
+		RepositoryScope("team/app", "push", "pull"),
+		RepositoryScope("team/base", "pull"),
+		Scope{Resource: "registry", Name: "catalog", Actions: []string{"*"}})
+	req, err := http.NewRequest("GET", "http://registry.corp/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.AuthorizeRequest(req, map[string]string{"realm": server.URL, "service": "registry.corp"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth := req.Header.Get("Authorization"); auth != "Bearer t0k3n" {
+		t.Fatalf("Expected the request to be authorized with the token, got %q", auth)
+	}
+	expected := []string{"repository:team/app:push,pull", "repository:team/base:pull", "registry:catalog:*"}
+	if !reflect.DeepEqual(query["scope"], expected) {
+		t.Fatalf("Expected the scopes %v, got %v", expected, query["scope"])
+	}
+	if query.Get("service") != "registry.corp" || query.Get("account") != "user" {
+		t.Fatalf("Expected the service and account to be sent, got %v", query)
+	}
+}
@@ -254,6 +254,7 @@ type HostConfig struct {
 	CgroupParent      string                // Parent cgroup.
 	ConsoleSize       [2]int                // Initial console size on Windows
 	VolumeDriver      string                // Name of the volume driver used to mount volumes
+	Tmpfs             map[string]string     // List of tmpfs (mounts) used for the container, with their options
 }
 
 // DecodeHostConfig creates a HostConfig based on the specified Reader.
@@ -2,11 +2,13 @@ package runconfig
 
 import (
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/sara-nl/docker-1.9.1/opts"
 	flag "github.com/sara-nl/docker-1.9.1/pkg/mflag"
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
 	"github.com/sara-nl/docker-1.9.1/pkg/nat"
 	"github.com/sara-nl/docker-1.9.1/pkg/parsers"
 	"github.com/sara-nl/docker-1.9.1/pkg/signal"
@@ -49,6 +51,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		// FIXME: use utils.ListOpts for attach and volumes?
 		flAttach  = opts.NewListOpts(opts.ValidateAttach)
 		flVolumes = opts.NewListOpts(opts.ValidatePath)
+		flTmpfs   = opts.NewListOpts(nil)
 		flLinks   = opts.NewListOpts(opts.ValidateLink)
 		flEnv     = opts.NewListOpts(opts.ValidateEnv)
 		flLabels  = opts.NewListOpts(opts.ValidateEnv)
@@ -109,6 +112,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR")
 	cmd.Var(&flVolumes, []string{"v", "-volume"}, "Bind mount a volume")
+	cmd.Var(&flTmpfs, []string{"-tmpfs"}, "Mount a tmpfs directory")
 	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container")
 	cmd.Var(&flDevices, []string{"-device"}, "Add a host device to the container")
 	cmd.Var(&flLabels, []string{"l", "-label"}, "Set meta data on a container")
@@ -216,6 +220,23 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	// the options are checked now, the tmpfs is only mounted when the
+	// container starts
+	tmpfs := make(map[string]string)
+	for _, t := range flTmpfs.GetAll() {
+		dest, options := t, ""
+		if arr := strings.SplitN(t, ":", 2); len(arr) > 1 {
+			dest, options = arr[0], arr[1]
+		}
+		if !path.IsAbs(dest) || path.Clean(dest) == "/" {
+			return nil, nil, cmd, fmt.Errorf("Invalid tmpfs mount: %q must be an absolute path other than '/'", dest)
+		}
+		if _, _, err := mount.ParseTmpfsOptions(options); err != nil {
+			return nil, nil, cmd, err
+		}
+		tmpfs[path.Clean(dest)] = options
+	}
+
 	var (
 		parsedArgs = cmd.Args()
 		runCmd     *stringutils.StrSlice
@@ -388,6 +409,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		LogConfig:      LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
 		CgroupParent:   *flCgroupParent,
 		VolumeDriver:   *flVolumeDriver,
+		Tmpfs:          tmpfs,
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
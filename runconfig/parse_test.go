@@ -175,6 +175,22 @@ func TestParseRunVolumes(t *testing.T) {
 	}
 }
 
+func TestParseRunTmpfs(t *testing.T) {
+	_, hostConfig := mustParse(t, "--tmpfs /run:rw,size=64m,mode=1777 --tmpfs /tmp/")
+	if len(hostConfig.Tmpfs) != 2 || hostConfig.Tmpfs["/run"] != "rw,size=64m,mode=1777" {
+		t.Fatalf("Error parsing tmpfs flags, `--tmpfs /run:rw,size=64m,mode=1777` should mount a tmpfs on /run. Received %v", hostConfig.Tmpfs)
+	}
+	if opts, exists := hostConfig.Tmpfs["/tmp"]; !exists || opts != "" {
+		t.Fatalf("Error parsing tmpfs flags, `--tmpfs /tmp/` should mount a tmpfs on /tmp without options. Received %v", hostConfig.Tmpfs)
+	}
+
+	for _, args := range []string{"--tmpfs /", "--tmpfs run", "--tmpfs /run:size=64m,foo=bar"} {
+		if _, _, err := parse(t, args); err == nil {
+			t.Fatalf("Error parsing tmpfs flags, `%s` should fail but didn't", args)
+		}
+	}
+}
+
 func TestParseLxcConfOpt(t *testing.T) {
 	opts := []string{"lxc.utsname=docker", "lxc.utsname = docker "}
 
@@ -7,78 +7,215 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/Sirupsen/logrus"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
 	"github.com/sara-nl/docker-1.9.1/volume"
+	"os"
 	"os/exec"
-	"strconv"
+	"path/filepath"
 	"strings"
 )
 
-const CephImageSizeMB = 1024 * 1024 // 1TB
+const (
+	CephImageSizeMB = 1024 * 1024 // 1TB
 
-func New() *Root {
-	return &Root{
+	driverName = "ceph"
+)
+
+// ErrNotFound is the typed error returned when the requested volume name can't be found
+var ErrNotFound = errors.New("volume not found")
+
+// New instantiates a new Root instance that keeps its state under the
+// given scope, and restores the volumes known to a previous daemon.
+func New(scope string) (*Root, error) {
+	rootDirectory := filepath.Join(scope, volumesPathName)
+	if err := os.MkdirAll(rootDirectory, 0700); err != nil {
+		return nil, err
+	}
+
+	r := &Root{
+		path:    rootDirectory,
 		volumes: make(map[string]*Volume),
 	}
+	if err := r.restore(); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
 type Root struct {
 	m       sync.Mutex
+	path    string
 	volumes map[string]*Volume
 }
 
+// List lists all the volumes
+func (r *Root) List() ([]volume.Volume, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var ls []volume.Volume
+	for _, v := range r.volumes {
+		ls = append(ls, v)
+	}
+	return ls, nil
+}
+
+// Get looks up the volume with the given name.
+func (r *Root) Get(name string) (volume.Volume, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	v, exists := r.volumes[name]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+// Scope returns the scope of the driver. RBD images are shared by all the
+// hosts using the Ceph cluster, so a volume name refers to the same image
+// on every host.
+func (r *Root) Scope() string {
+	return volume.GlobalScope
+}
+
 func (r *Root) Name() string {
-	return "ceph"
+	return driverName
 }
 
-func (r *Root) Create(name string, _ map[string]string) (volume.Volume, error) {
+func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error) {
 	r.m.Lock()
 	defer r.m.Unlock()
 
 	v, exists := r.volumes[name]
 	if !exists {
+		o, err := parseImageOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+		if o.SourceVolume != "" {
+			return r.clone(name, o, opts)
+		}
+
 		//TODO: Might want to map with --options rw/ro here, but then we need to sneak in the RW flag somehow
 		var stdout bytes.Buffer
 		var stderr bytes.Buffer
 		var mappedDevicePath string
+		spec := imageSpec(o.Pool, name)
 
-		cmd := exec.Command("rbd", "create", name, "--size", strconv.Itoa(CephImageSizeMB))
+		cmd := exec.Command("rbd", o.createArgs(name)...)
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 		if err := cmd.Run(); err == nil {
-			logrus.Infof("Created Ceph volume %s", name)
-			mappedDevicePath, err = mapCephVolume(name)
+			logrus.Infof("Created Ceph volume %s (%d MB)", spec, o.SizeMB)
+			mappedDevicePath, err = mapCephVolume(spec)
 			if err != nil {
 				return nil, err
 			}
-			cmd = exec.Command("mkfs.ext4", "-m0", mappedDevicePath)
-			logrus.Infof("Creating ext4 filesystem in newly created Ceph volume %s (device %s)", name, mappedDevicePath)
-			if err := cmd.Run(); err != nil {
-				logrus.Errorf("Failed to create ext4 filesystem in newly created Ceph volume %s (device %s) - %s", name, mappedDevicePath, err)
+			if err := makeFilesystem(o.FsType, mappedDevicePath); err != nil {
+				logrus.Errorf("Failed to create %s filesystem in newly created Ceph volume %s (device %s) - %s", o.FsType, spec, mappedDevicePath, err)
+				unmapCephVolume(spec, mappedDevicePath)
+				removeCephVolume(spec)
 				return nil, err
 			}
 		} else if strings.Contains(stderr.String(), fmt.Sprintf("rbd image %s already exists", name)) {
-			logrus.Infof("Found existing Ceph volume %s", name)
-			mappedDevicePath, err = mapCephVolume(name)
+			logrus.Infof("Found existing Ceph volume %s", spec)
+			mappedDevicePath, err = mapCephVolume(spec)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			msg := fmt.Sprintf("Failed to create Ceph volume %s - %s", name, err)
-			logrus.Errorf(msg)
+			msg := fmt.Sprintf("Failed to create Ceph volume %s - %s - %s", spec, err, strings.TrimRight(stderr.String(), "\n"))
+			logrus.Error(msg)
 			return nil, errors.New(msg)
 		}
 
 		v = &Volume{
 			driverName:       r.Name(),
 			name:             name,
+			pool:             o.Pool,
+			opts:             opts,
 			mappedDevicePath: mappedDevicePath,
 		}
 		r.volumes[name] = v
 	}
 	v.use()
+	if err := r.saveState(); err != nil {
+		logrus.Errorf("Failed to save the state of Ceph volume %s: %v", name, err)
+	}
 	return v, nil
 }
 
+// clone creates a volume from a snapshot of another volume. It must be
+// called with r.m held.
+func (r *Root) clone(name string, o *imageOptions, opts map[string]string) (volume.Volume, error) {
+	src, exists := r.volumes[o.SourceVolume]
+	if !exists {
+		return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(opts[optSnapshot], optSnapshot, driverName, "no such Ceph volume "+o.SourceVolume)
+	}
+	if o.Pool == "" {
+		o.Pool = src.pool
+	}
+
+	if _, err := runRbd(o.cloneArgs(src.snapshotSpec(o.SourceSnapshot), name)...); err != nil {
+		return nil, err
+	}
+	spec := imageSpec(o.Pool, name)
+	logrus.Infof("Cloned Ceph volume %s from snapshot %s of %s", spec, o.SourceSnapshot, o.SourceVolume)
+
+	mappedDevicePath, err := mapCephVolume(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the filesystem of the parent, it is needed to mount the clone
+	cloneOpts := make(map[string]string)
+	for k, val := range opts {
+		cloneOpts[k] = val
+	}
+	if fsType, ok := src.opts[optFsType]; ok {
+		cloneOpts[optFsType] = fsType
+	}
+
+	v := &Volume{
+		driverName:       r.Name(),
+		name:             name,
+		pool:             o.Pool,
+		opts:             cloneOpts,
+		mappedDevicePath: mappedDevicePath,
+	}
+	r.volumes[name] = v
+	v.use()
+	if err := r.saveState(); err != nil {
+		logrus.Errorf("Failed to save the state of Ceph volume %s: %v", name, err)
+	}
+	return v, nil
+}
+
+// runRbd runs rbd with the given arguments and returns its output. It is a
+// variable so that tests can stub the rbd command.
+var runRbd = func(args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("rbd", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("rbd %s: %s - %s", strings.Join(args, " "), err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return stdout.Bytes(), nil
+}
+
+// makeFilesystem formats the device with the given filesystem type.
+func makeFilesystem(fsType, devicePath string) error {
+	args := append(append([]string{}, mkfsArgs[fsType]...), devicePath)
+	cmd := exec.Command("mkfs."+fsType, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	logrus.Infof("Creating %s filesystem on device %s", fsType, devicePath)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("mkfs.%s %s: %s - %s", fsType, devicePath, err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return nil
+}
+
 func mapCephVolume(name string) (string, error) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -92,12 +229,25 @@ func mapCephVolume(name string) (string, error) {
 		return mappedDevicePath, nil
 	} else {
 		msg := fmt.Sprintf("Failed to map Ceph volume %s: %s - %s", name, err, strings.TrimRight(stderr.String(), "\n"))
-		logrus.Errorf(msg)
+		logrus.Error(msg)
 		return "", errors.New(msg)
 	}
 
 }
 
+func removeCephVolume(name string) error {
+	cmd := exec.Command("rbd", "rm", name)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		logrus.Infof("Removed Ceph volume %s", name)
+	} else {
+		logrus.Errorf("Failed to remove Ceph volume %s: %s - %s", name, err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return err
+}
+
 func (r *Root) Remove(v volume.Volume) error {
 	r.m.Lock()
 	defer r.m.Unlock()
@@ -107,11 +257,18 @@ func (r *Root) Remove(v volume.Volume) error {
 		return errors.New("unknown volume type")
 	}
 	lv.release()
-	if lv.usedCount == 0 {
-		unmapCephVolume(lv.name, lv.mappedDevicePath)
+	if lv.usedCount <= 0 {
+		// a lock left behind by a daemon that did not shut down cleanly
+		// would keep the other hosts from mounting the image
+		if err := unlockImage(imageSpec(lv.pool, lv.name)); err != nil {
+			logrus.Warnf("Failed to release the lock on Ceph volume %s: %v", lv.name, err)
+		}
+		if lv.mappedDevicePath != "" {
+			unmapCephVolume(imageSpec(lv.pool, lv.name), lv.mappedDevicePath)
+		}
 		delete(r.volumes, lv.name)
 	}
-	return nil
+	return r.saveState()
 }
 
 func unmapCephVolume(name, mappedDevicePath string) error {
@@ -134,8 +291,14 @@ type Volume struct {
 	name string
 	// driverName is the name of the driver that created the volume.
 	driverName string
+	// the Ceph pool the RBD image lives in, empty for the default pool
+	pool string
+	// the driver options the volume was created with
+	opts map[string]string
 	// the path to the device to which the Ceph volume has been mapped
 	mappedDevicePath string
+	// the mounts on this host that were granted access, by mount id
+	holders map[string]*accessHolder
 }
 
 func (v *Volume) Name() string {
@@ -150,12 +313,33 @@ func (v *Volume) Path() string {
 	return ""
 }
 
-func (v *Volume) Mount() (string, error) {
+func (v *Volume) Mount(id, containerID string) (string, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.mappedDevicePath == "" {
+		// mapping the image failed when the volume was restored
+		mappedDevicePath, err := mapCephVolume(imageSpec(v.pool, v.name))
+		if err != nil {
+			return "", err
+		}
+		v.mappedDevicePath = mappedDevicePath
+	}
 	// The return value from this method will be passed to the container
 	return v.mappedDevicePath, nil
 }
 
-func (v *Volume) Unmount() error {
+// MountType returns the filesystem of the mapped device, as it is mounted
+// into the container rather than bind mounted.
+func (v *Volume) MountType() (string, string) {
+	fsType := v.opts[optFsType]
+	if fsType == "" {
+		// the image might predate the fstype option, let mount detect it
+		fsType = "auto"
+	}
+	return fsType, "discard"
+}
+
+func (v *Volume) Unmount(id, containerID string) error {
 	return nil
 }
 
@@ -0,0 +1,207 @@
+package cephvolumedriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/pkg/stringid"
+)
+
+// lockTag is the tag of the shared RBD lock taken by the hosts that mount
+// a volume read-only.
+const lockTag = "docker-readers"
+
+// accessHolder is a mount that was granted access to a volume.
+type accessHolder struct {
+	containerID string
+	rw          bool
+	count       int
+}
+
+// imageLock is an entry in the output of `rbd lock list`.
+type imageLock struct {
+	ID      string `json:"id"`
+	Locker  string `json:"locker"`
+	Address string `json:"address"`
+}
+
+// LockAccess grants the mount with the given id access to the volume. The
+// filesystem on the image is not cluster aware, so the volume can be
+// mounted read-write by a single container, or read-only by many. The
+// mounts of a same container, such as the transient mounts made to copy
+// files while it runs, share the access of the container. On top of the
+// mounts on this host, an RBD advisory lock keeps the other hosts from
+// mounting the volume in a conflicting mode.
+func (v *Volume) LockAccess(id, containerID string, rw bool) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if err := v.checkAccess(id, containerID, rw); err != nil {
+		return err
+	}
+	if len(v.holders) == 0 {
+		if err := lockImage(v.name, imageSpec(v.pool, v.name), rw); err != nil {
+			return err
+		}
+		v.holders = make(map[string]*accessHolder)
+	}
+
+	h, exists := v.holders[id]
+	if !exists {
+		h = &accessHolder{containerID: containerID}
+		v.holders[id] = h
+	}
+	h.count++
+	if rw {
+		h.rw = true
+	}
+	return nil
+}
+
+// UnlockAccess releases one lock of the mount with the given id, and the
+// RBD lock once no mount on this host uses the volume anymore.
+func (v *Volume) UnlockAccess(id string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	h, exists := v.holders[id]
+	if !exists {
+		return nil
+	}
+	h.count--
+	if h.count > 0 {
+		return nil
+	}
+	delete(v.holders, id)
+	if len(v.holders) > 0 {
+		return nil
+	}
+	return unlockImage(imageSpec(v.pool, v.name))
+}
+
+// checkAccess returns an error if the mounts on this host keep the mount
+// with the given id, made for the container containerID, from using the
+// volume. Mounts of the same container never conflict. It must be called
+// with v.m held.
+func (v *Volume) checkAccess(id, containerID string, rw bool) error {
+	for holderID, h := range v.holders {
+		if holderID == id || (containerID != "" && h.containerID == containerID) {
+			continue
+		}
+		if rw || h.rw {
+			return derr.ErrorCodeVolumeLocked.WithArgs(v.name, accessMode(rw), describeHolder(h))
+		}
+	}
+	return nil
+}
+
+func accessMode(rw bool) string {
+	if rw {
+		return "read-write"
+	}
+	return "read-only"
+}
+
+func describeHolder(h *accessHolder) string {
+	if h.containerID == "" {
+		return "the daemon on this host"
+	}
+	return "container " + stringid.TruncateID(h.containerID)
+}
+
+// hostLockID returns the id of the RBD locks taken by this host.
+func hostLockID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return "docker-" + hostname
+}
+
+// lockImage takes the RBD lock of this host on the image, exclusive for
+// read-write access and shared for read-only access.
+func lockImage(name, spec string, rw bool) error {
+	id := hostLockID()
+	locks, err := listLocks(spec)
+	if err != nil {
+		return err
+	}
+	for _, l := range locks {
+		if l.ID == id {
+			logrus.Infof("Releasing stale lock %s on Ceph volume %s", id, spec)
+			if err := removeLock(spec, l); err != nil {
+				return err
+			}
+		}
+	}
+
+	args := []string{"lock", "add", spec, id}
+	if !rw {
+		args = []string{"lock", "add", "--shared", lockTag, spec, id}
+	}
+	if _, err := runRbd(args...); err != nil {
+		if locks, lerr := listLocks(spec); lerr == nil && len(locks) > 0 {
+			return derr.ErrorCodeVolumeLocked.WithArgs(name, accessMode(rw), "another host ("+locks[0].Address+")")
+		}
+		return err
+	}
+	return nil
+}
+
+// unlockImage releases the RBD lock of this host on the image, if any.
+func unlockImage(spec string) error {
+	locks, err := listLocks(spec)
+	if err != nil {
+		return err
+	}
+	id := hostLockID()
+	for _, l := range locks {
+		if l.ID == id {
+			return removeLock(spec, l)
+		}
+	}
+	return nil
+}
+
+func removeLock(spec string, l imageLock) error {
+	_, err := runRbd("lock", "remove", spec, l.ID, l.Locker)
+	return err
+}
+
+func listLocks(spec string) ([]imageLock, error) {
+	out, err := runRbd("lock", "list", "--format", "json", spec)
+	if err != nil {
+		return nil, err
+	}
+	return parseLockList(out)
+}
+
+// parseLockList decodes the JSON output of `rbd lock list`, which is an
+// object keyed by lock id in older releases and a list in newer ones.
+func parseLockList(b []byte) ([]imageLock, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	var locks []imageLock
+	if b[0] == '[' {
+		if err := json.Unmarshal(b, &locks); err != nil {
+			return nil, err
+		}
+		return locks, nil
+	}
+
+	var byID map[string]imageLock
+	if err := json.Unmarshal(b, &byID); err != nil {
+		return nil, err
+	}
+	for id, l := range byID {
+		l.ID = id
+		locks = append(locks, l)
+	}
+	return locks, nil
+}
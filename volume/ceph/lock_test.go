@@ -0,0 +1,87 @@
+package cephvolumedriver
+
+import (
+	"testing"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+)
+
+func TestParseLockList(t *testing.T) {
+	for _, out := range []string{
+		`[{"id":"docker-host1","locker":"client.4123","address":"10.0.0.1:0/1234"}]`,
+		`{"docker-host1":{"locker":"client.4123","address":"10.0.0.1:0/1234"}}`,
+	} {
+		locks, err := parseLockList([]byte(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(locks) != 1 {
+			t.Fatalf("Expected 1 lock, got %v", locks)
+		}
+		expected := imageLock{ID: "docker-host1", Locker: "client.4123", Address: "10.0.0.1:0/1234"}
+		if locks[0] != expected {
+			t.Fatalf("Expected lock %v, got %v", expected, locks[0])
+		}
+	}
+
+	locks, err := parseLockList([]byte("\n"))
+	if err != nil || len(locks) != 0 {
+		t.Fatalf("Expected no locks, got %v (%v)", locks, err)
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	v := &Volume{name: "db", holders: map[string]*accessHolder{
+		"reader1": {containerID: "c1", count: 1},
+	}}
+	if err := v.checkAccess("reader2", "c2", false); err != nil {
+		t.Fatalf("Expected a second reader to be granted access, got %v", err)
+	}
+	if err := v.checkAccess("writer", "c2", true); !isVolumeLocked(err) {
+		t.Fatalf("Expected a writer to be refused access to a volume in use, got %v", err)
+	}
+
+	v = &Volume{name: "db", holders: map[string]*accessHolder{
+		"writer": {containerID: "c1", rw: true, count: 1},
+	}}
+	if err := v.checkAccess("writer", "c1", true); err != nil {
+		t.Fatalf("Expected the writer to lock the volume again, got %v", err)
+	}
+	for _, rw := range []bool{true, false} {
+		if err := v.checkAccess("other", "c2", rw); !isVolumeLocked(err) {
+			t.Fatalf("Expected a second mount to be refused access to a volume in use read-write, got %v", err)
+		}
+		if err := v.checkAccess("other", "", rw); !isVolumeLocked(err) {
+			t.Fatalf("Expected the daemon to be refused access to a volume in use read-write, got %v", err)
+		}
+	}
+}
+
+func TestLockAccessSameContainer(t *testing.T) {
+	// The RBD lock is only taken by the first mount, which is already
+	// in place.
+	v := &Volume{name: "db", holders: map[string]*accessHolder{
+		"running": {containerID: "c1", rw: true, count: 1},
+	}}
+
+	// Copying files to or from the running container mounts the volume
+	// again for the container, with a mount id of its own.
+	if err := v.LockAccess("copy", "c1", true); err != nil {
+		t.Fatalf("Expected a second mount of the container to be granted access, got %v", err)
+	}
+	if err := v.UnlockAccess("copy"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the copy is done, the volume is still in use read-write.
+	if err := v.checkAccess("other", "c2", false); !isVolumeLocked(err) {
+		t.Fatalf("Expected the volume to stay locked by the running container, got %v", err)
+	}
+}
+
+// isVolumeLocked returns whether err reports that the volume is locked.
+func isVolumeLocked(err error) bool {
+	e, ok := err.(errcode.Error)
+	return ok && e.ErrorCode() == derr.ErrorCodeVolumeLocked
+}
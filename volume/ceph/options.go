@@ -0,0 +1,156 @@
+package cephvolumedriver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/pkg/units"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
+
+const (
+	optSize     = "size"
+	optPool     = "pool"
+	optFsType   = "fstype"
+	optFeatures = "image-features"
+	optSnapshot = volume.SnapshotOpt
+
+	defaultFsType = "ext4"
+)
+
+var (
+	// poolNameRegex restricts pool names to what rbd accepts on the command
+	// line without the name being mistaken for an image spec.
+	poolNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+	// mkfsArgs maps the supported filesystems to the extra arguments passed
+	// to the matching mkfs.<fstype> command.
+	mkfsArgs = map[string][]string{
+		"ext3": {"-m0"},
+		"ext4": {"-m0"},
+		"xfs":  {},
+	}
+
+	// imageFeatures lists the RBD image features that may be requested.
+	imageFeatures = map[string]bool{
+		"layering":       true,
+		"striping":       true,
+		"exclusive-lock": true,
+		"object-map":     true,
+		"fast-diff":      true,
+		"deep-flatten":   true,
+		"journaling":     true,
+	}
+)
+
+// imageOptions holds the driver options used to create an RBD image.
+type imageOptions struct {
+	SizeMB   int64
+	Pool     string
+	FsType   string
+	Features []string
+	// SourceVolume and SourceSnapshot name the snapshot the image is
+	// cloned from, if any.
+	SourceVolume   string
+	SourceSnapshot string
+}
+
+// parseImageOptions validates the driver options passed to Create and
+// fills in the defaults for the ones that are not set.
+func parseImageOptions(opts map[string]string) (*imageOptions, error) {
+	o := &imageOptions{
+		SizeMB: CephImageSizeMB,
+		FsType: defaultFsType,
+	}
+
+	for key, val := range opts {
+		switch key {
+		case optSize:
+			size, err := units.RAMInBytes(val)
+			if err != nil {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, err)
+			}
+			if size < 1024*1024 {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "size must be at least 1M")
+			}
+			o.SizeMB = sizeInMB(size)
+		case optPool:
+			if !poolNameRegex.MatchString(val) {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "pool names may only contain [a-zA-Z0-9_.-]")
+			}
+			o.Pool = val
+		case optFsType:
+			if _, ok := mkfsArgs[val]; !ok {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, fmt.Sprintf("supported filesystems are %s", supportedFsTypes()))
+			}
+			o.FsType = val
+		case optFeatures:
+			for _, f := range strings.Split(val, ",") {
+				f = strings.TrimSpace(f)
+				if !imageFeatures[f] {
+					return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, fmt.Sprintf("unknown image feature %q", f))
+				}
+				o.Features = append(o.Features, f)
+			}
+		case optSnapshot:
+			var ok bool
+			if o.SourceVolume, o.SourceSnapshot, ok = parseSnapshotOpt(val); !ok {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "expected VOLUME@SNAPSHOT")
+			}
+		default:
+			return nil, derr.ErrorCodeVolumeOptUnknown.WithArgs(key, driverName)
+		}
+	}
+
+	if o.SourceVolume != "" {
+		// clones have the size and filesystem of their parent
+		for _, key := range []string{optSize, optFsType} {
+			if val, ok := opts[key]; ok {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "can not be used along with the snapshot option")
+			}
+		}
+	}
+	return o, nil
+}
+
+// createArgs returns the arguments for `rbd create` of the named image.
+func (o *imageOptions) createArgs(name string) []string {
+	args := []string{"create", name, "--size", fmt.Sprintf("%d", o.SizeMB)}
+	if o.Pool != "" {
+		args = append(args, "--pool", o.Pool)
+	}
+	for _, f := range o.Features {
+		args = append(args, "--image-feature", f)
+	}
+	return args
+}
+
+// cloneArgs returns the arguments for `rbd clone` of the given snapshot
+// into the named image.
+func (o *imageOptions) cloneArgs(snapshot, name string) []string {
+	args := []string{"clone", snapshot, imageSpec(o.Pool, name)}
+	for _, f := range o.Features {
+		args = append(args, "--image-feature", f)
+	}
+	return args
+}
+
+// imageSpec returns the name rbd uses for an image in the given pool.
+func imageSpec(pool, name string) string {
+	if pool == "" {
+		return name
+	}
+	return pool + "/" + name
+}
+
+func supportedFsTypes() string {
+	var types []string
+	for t := range mkfsArgs {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
@@ -0,0 +1,92 @@
+package cephvolumedriver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseImageOptionsDefaults(t *testing.T) {
+	o, err := parseImageOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.SizeMB != CephImageSizeMB {
+		t.Fatalf("Expected default size %d, got %d", CephImageSizeMB, o.SizeMB)
+	}
+	if o.FsType != defaultFsType {
+		t.Fatalf("Expected default fstype %s, got %s", defaultFsType, o.FsType)
+	}
+	if o.Pool != "" || len(o.Features) != 0 {
+		t.Fatalf("Expected no pool and features, got %q and %v", o.Pool, o.Features)
+	}
+}
+
+func TestParseImageOptions(t *testing.T) {
+	o, err := parseImageOptions(map[string]string{
+		"size":           "20G",
+		"pool":           "fast",
+		"fstype":         "xfs",
+		"image-features": "layering, exclusive-lock",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.SizeMB != 20*1024 {
+		t.Fatalf("Expected size of 20480 MB, got %d", o.SizeMB)
+	}
+
+	expected := []string{"create", "vol", "--size", "20480", "--pool", "fast", "--image-feature", "layering", "--image-feature", "exclusive-lock"}
+	if args := o.createArgs("vol"); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected rbd arguments %v, got %v", expected, args)
+	}
+	if spec := imageSpec(o.Pool, "vol"); spec != "fast/vol" {
+		t.Fatalf("Expected image spec fast/vol, got %s", spec)
+	}
+}
+
+func TestParseImageOptionsInvalid(t *testing.T) {
+	for _, opts := range []map[string]string{
+		{"size": "lots"},
+		{"size": "100k"},
+		{"pool": "../etc"},
+		{"pool": ""},
+		{"fstype": "ntfs"},
+		{"image-features": "layering,teleport"},
+		{"unknown": "value"},
+	} {
+		if _, err := parseImageOptions(opts); err == nil {
+			t.Fatalf("Expected an error for options %v", opts)
+		}
+	}
+}
+
+func TestParseImageOptionsSnapshot(t *testing.T) {
+	o, err := parseImageOptions(map[string]string{
+		"snapshot": "db@before-upgrade",
+		"pool":     "fast",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.SourceVolume != "db" || o.SourceSnapshot != "before-upgrade" {
+		t.Fatalf("Expected snapshot before-upgrade of db, got %s of %s", o.SourceSnapshot, o.SourceVolume)
+	}
+
+	expected := []string{"clone", "rbd/db@before-upgrade", "fast/clone"}
+	if args := o.cloneArgs("rbd/db@before-upgrade", "clone"); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected rbd arguments %v, got %v", expected, args)
+	}
+
+	for _, opts := range []map[string]string{
+		{"snapshot": "db"},
+		{"snapshot": "@snap"},
+		{"snapshot": "db@"},
+		{"snapshot": "db@snap/shot"},
+		{"snapshot": "db@snap", "size": "10G"},
+		{"snapshot": "db@snap", "fstype": "xfs"},
+	} {
+		if _, err := parseImageOptions(opts); err == nil {
+			t.Fatalf("Expected an error for options %v", opts)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package cephvolumedriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
+	"github.com/sara-nl/docker-1.9.1/pkg/units"
+)
+
+// imageInfo is the output of `rbd info`.
+type imageInfo struct {
+	Size int64 `json:"size"`
+}
+
+// Capacity returns the size of the RBD image.
+func (v *Volume) Capacity() (int64, error) {
+	out, err := runRbd("info", imageSpec(v.pool, v.name), "--format", "json")
+	if err != nil {
+		return -1, err
+	}
+	var info imageInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		return -1, err
+	}
+	return info.Size, nil
+}
+
+// growFS grows the filesystem on a mapped device. It is a variable so that
+// tests can stub it.
+var growFS = growFilesystem
+
+// sizeInMB returns size in megabytes, rounded up to the next one, as rbd
+// expects sizes in megabytes.
+func sizeInMB(size int64) int64 {
+	return (size + units.MiB - 1) / units.MiB
+}
+
+// Resize grows the RBD image to the given size, rounded up to a megabyte,
+// and then grows the filesystem on it. Both can be done while the volume
+// is used by containers.
+func (v *Volume) Resize(size int64) error {
+	// the capacity is read under the lock, so that a concurrent resize
+	// cannot change it between the check and the resize
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	capacity, err := v.Capacity()
+	if err != nil {
+		return err
+	}
+	sizeMB := sizeInMB(size)
+	if sizeMB*units.MiB < capacity {
+		return derr.ErrorCodeVolumeShrink.WithArgs(v.name, units.BytesSize(float64(capacity)), units.BytesSize(float64(size)))
+	}
+
+	spec := imageSpec(v.pool, v.name)
+	if sizeMB*units.MiB > capacity {
+		if _, err := runRbd("resize", "--size", fmt.Sprintf("%d", sizeMB), spec); err != nil {
+			return err
+		}
+		logrus.Infof("Resized Ceph volume %s to %d MB", spec, sizeMB)
+	}
+
+	if v.mappedDevicePath == "" {
+		mappedDevicePath, err := mapCephVolume(spec)
+		if err != nil {
+			return err
+		}
+		v.mappedDevicePath = mappedDevicePath
+	}
+	// the filesystem is grown even if the image already had the requested
+	// size, to complete a resize whose first attempt failed halfway
+	return growFS(v.fsType(), v.mappedDevicePath)
+}
+
+// fsType returns the filesystem on the mapped device of the volume,
+// falling back to the one it was created with.
+func (v *Volume) fsType() string {
+	out, err := exec.Command("blkid", "-o", "value", "-s", "TYPE", v.mappedDevicePath).Output()
+	if fsType := strings.TrimSpace(string(out)); err == nil && fsType != "" {
+		return fsType
+	}
+	if fsType, ok := v.opts[optFsType]; ok {
+		return fsType
+	}
+	return defaultFsType
+}
+
+// growFilesystem grows the filesystem on the device to the size of the
+// device. The device is mounted on the host for the duration of the
+// resize; when it is also mounted by a container, both mounts share the
+// same filesystem instance, so it is grown online.
+func growFilesystem(fsType, devicePath string) error {
+	var grow []string
+	switch fsType {
+	case "ext2", "ext3", "ext4":
+		grow = []string{"resize2fs", devicePath}
+	case "xfs":
+		grow = []string{"xfs_growfs"}
+	default:
+		return fmt.Errorf("growing a %s filesystem is not supported", fsType)
+	}
+
+	dir, err := ioutil.TempDir("", "docker-ceph-resize")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dir)
+
+	if err := mount.Mount(devicePath, dir, fsType, ""); err != nil {
+		return fmt.Errorf("Failed to mount %s to grow its filesystem: %v", devicePath, err)
+	}
+	defer func() {
+		if err := mount.Unmount(dir); err != nil {
+			logrus.Errorf("Failed to unmount %s from %s: %v", devicePath, dir, err)
+		}
+	}()
+
+	if fsType == "xfs" {
+		grow = append(grow, dir)
+	}
+	cmd := exec.Command(grow[0], grow[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s - %s", strings.Join(grow, " "), err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	logrus.Infof("Grew %s filesystem on %s", fsType, devicePath)
+	return nil
+}
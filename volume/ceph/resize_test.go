@@ -0,0 +1,112 @@
+package cephvolumedriver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sara-nl/docker-1.9.1/pkg/units"
+)
+
+func TestGrowFilesystemUnsupported(t *testing.T) {
+	if err := growFilesystem("btrfs", "/dev/rbd0"); err == nil {
+		t.Fatal("Expected an error when growing an unsupported filesystem")
+	}
+}
+
+// stubResize replaces the rbd command with one reporting an image of
+// capacity bytes and recording the resizes, and the growing of filesystems
+// with one recording the devices grown. It returns a function restoring
+// them.
+func stubResize(capacity int64, resizes *[]string, grown *[]string) func() {
+	origRunRbd, origGrowFS := runRbd, growFS
+	runRbd = func(args ...string) ([]byte, error) {
+		switch args[0] {
+		case "info":
+			return []byte(fmt.Sprintf(`{"size": %d}`, capacity)), nil
+		case "resize":
+			*resizes = append(*resizes, strings.Join(args, " "))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected rbd command %v", args)
+	}
+	growFS = func(fsType, devicePath string) error {
+		*grown = append(*grown, fsType+" "+devicePath)
+		return nil
+	}
+	return func() {
+		runRbd, growFS = origRunRbd, origGrowFS
+	}
+}
+
+func TestResize(t *testing.T) {
+	cases := []struct {
+		size     string
+		capacity int64
+		resize   string
+		err      bool
+	}{
+		// grow, to sizes rounded up to a megabyte
+		{size: "20G", capacity: 10 * units.GiB, resize: "resize --size 20480 rbd/vol"},
+		{size: "1536m", capacity: units.GiB, resize: "resize --size 1536 rbd/vol"},
+		{size: "1048577", capacity: units.MiB, resize: "resize --size 2 rbd/vol"},
+		{size: "100m", capacity: 50*units.MiB + 1, resize: "resize --size 100 rbd/vol"},
+		// same size, only the filesystem is grown
+		{size: "10G", capacity: 10 * units.GiB},
+		{size: "10737418239", capacity: 10 * units.GiB},
+		// shrink
+		{size: "5G", capacity: 10 * units.GiB, err: true},
+		{size: "10239m", capacity: 10 * units.GiB, err: true},
+	}
+
+	for _, c := range cases {
+		size, err := units.RAMInBytes(c.size)
+		if err != nil {
+			t.Fatalf("Invalid size %s: %v", c.size, err)
+		}
+
+		var resizes, grown []string
+		restore := stubResize(c.capacity, &resizes, &grown)
+		v := &Volume{name: "vol", pool: "rbd", mappedDevicePath: "/dev/rbd0", opts: map[string]string{optFsType: "xfs"}}
+		err = v.Resize(size)
+		restore()
+
+		if c.err {
+			if err == nil || !strings.Contains(err.Error(), "shrink") {
+				t.Fatalf("Expected resizing a %d byte volume to %s to be rejected, got %v", c.capacity, c.size, err)
+			}
+			if len(resizes) != 0 || len(grown) != 0 {
+				t.Fatalf("Expected a rejected resize to %s not to touch the volume, got %v and %v", c.size, resizes, grown)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Resizing to %s failed: %v", c.size, err)
+		}
+		if c.resize == "" && len(resizes) != 0 {
+			t.Fatalf("Expected no rbd resize to %s, got %v", c.size, resizes)
+		}
+		if c.resize != "" && (len(resizes) != 1 || resizes[0] != c.resize) {
+			t.Fatalf("Expected %q resizing to %s, got %v", c.resize, c.size, resizes)
+		}
+		if len(grown) != 1 || grown[0] != "xfs /dev/rbd0" {
+			t.Fatalf("Expected the xfs filesystem on /dev/rbd0 to be grown, got %v", grown)
+		}
+	}
+}
+
+func TestSizeInMB(t *testing.T) {
+	for size, expected := range map[int64]int64{
+		0:                 0,
+		1:                 1,
+		units.MiB:         1,
+		units.MiB + 1:     2,
+		units.GiB:         1024,
+		10*units.GiB - 1:  10240,
+		10*units.GiB + 42: 10241,
+	} {
+		if mb := sizeInMB(size); mb != expected {
+			t.Fatalf("Expected %d bytes to be %d MB, got %d", size, expected, mb)
+		}
+	}
+}
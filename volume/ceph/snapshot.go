@@ -0,0 +1,92 @@
+package cephvolumedriver
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/utils"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
+
+var snapshotNameRegex = regexp.MustCompile(`^` + utils.RestrictedNameChars + `+$`)
+
+// rbdSnapshot is an entry in the output of `rbd snap ls`.
+type rbdSnapshot struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// CreateSnapshot takes a snapshot of the RBD image and protects it, so
+// that volumes can be cloned from it.
+func (v *Volume) CreateSnapshot(name string) error {
+	if !snapshotNameRegex.MatchString(name) {
+		return derr.ErrorCodeVolumeSnapshotName.WithArgs(name, utils.RestrictedNameChars)
+	}
+	snap := v.snapshotSpec(name)
+	if _, err := runRbd("snap", "create", snap); err != nil {
+		return err
+	}
+	logrus.Infof("Created snapshot %s of Ceph volume %s", name, v.name)
+
+	// Only images with the layering feature can be cloned, snapshots of
+	// other images are still usable but can't be protected.
+	if _, err := runRbd("snap", "protect", snap); err != nil {
+		logrus.Warnf("Snapshot %s can not be cloned: %v", snap, err)
+	}
+	return nil
+}
+
+// ListSnapshots returns the snapshots of the RBD image.
+func (v *Volume) ListSnapshots() ([]volume.Snapshot, error) {
+	out, err := runRbd("snap", "ls", imageSpec(v.pool, v.name), "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var snaps []rbdSnapshot
+	if len(strings.TrimSpace(string(out))) > 0 {
+		if err := json.Unmarshal(out, &snaps); err != nil {
+			return nil, err
+		}
+	}
+
+	ls := make([]volume.Snapshot, 0, len(snaps))
+	for _, s := range snaps {
+		ls = append(ls, volume.Snapshot{Name: s.Name, Size: s.Size})
+	}
+	return ls, nil
+}
+
+// RemoveSnapshot removes a snapshot of the RBD image. It fails while
+// volumes cloned from the snapshot exist.
+func (v *Volume) RemoveSnapshot(name string) error {
+	if !snapshotNameRegex.MatchString(name) {
+		return derr.ErrorCodeVolumeSnapshotName.WithArgs(name, utils.RestrictedNameChars)
+	}
+	snap := v.snapshotSpec(name)
+	// unprotecting fails for snapshots that were never protected, in which
+	// case removing them works, or that still have clones, in which case
+	// removing them fails with an explicit error
+	runRbd("snap", "unprotect", snap)
+	if _, err := runRbd("snap", "rm", snap); err != nil {
+		return err
+	}
+	logrus.Infof("Removed snapshot %s of Ceph volume %s", name, v.name)
+	return nil
+}
+
+func (v *Volume) snapshotSpec(name string) string {
+	return imageSpec(v.pool, v.name) + "@" + name
+}
+
+// parseSnapshotOpt splits the value of the snapshot option into the names
+// of the volume and of its snapshot.
+func parseSnapshotOpt(val string) (string, string, bool) {
+	parts := strings.SplitN(val, "@", 2)
+	if len(parts) != 2 || parts[0] == "" || !snapshotNameRegex.MatchString(parts[1]) {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,188 @@
+package cephvolumedriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	volumesPathName = "ceph-volumes"
+	stateFileName   = "volumes.json"
+	defaultPool     = "rbd"
+)
+
+// volumeRecord is the on-disk representation of a volume, used to
+// rediscover the volumes after a daemon restart.
+type volumeRecord struct {
+	Name             string
+	Pool             string            `json:",omitempty"`
+	Opts             map[string]string `json:",omitempty"`
+	MappedDevicePath string
+	UsedCount        int
+}
+
+// mappedImage is an entry in the output of `rbd showmapped`.
+type mappedImage struct {
+	Pool   string `json:"pool"`
+	Name   string `json:"name"`
+	Snap   string `json:"snap"`
+	Device string `json:"device"`
+}
+
+func (r *Root) statePath() string {
+	return filepath.Join(r.path, stateFileName)
+}
+
+// saveState writes the records of all the known volumes to disk.
+// It must be called with r.m held.
+func (r *Root) saveState() error {
+	records := make([]volumeRecord, 0, len(r.volumes))
+	for _, v := range r.volumes {
+		v.m.Lock()
+		records = append(records, volumeRecord{
+			Name:             v.name,
+			Pool:             v.pool,
+			Opts:             v.opts,
+			MappedDevicePath: v.mappedDevicePath,
+			UsedCount:        v.usedCount,
+		})
+		v.m.Unlock()
+	}
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	tmp := r.statePath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.statePath())
+}
+
+// restore loads the volumes persisted by a previous daemon and reconciles
+// them with the RBD images currently mapped on the host. Volumes still in
+// use are mapped again if needed, and images of volumes that were being
+// removed are unmapped.
+func (r *Root) restore() error {
+	b, err := ioutil.ReadFile(r.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var records []volumeRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return fmt.Errorf("Error reading Ceph volume state %s: %v", r.statePath(), err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	mapped, err := showMapped()
+	if err != nil {
+		logrus.Warnf("Unable to list mapped Ceph volumes, falling back to the recorded devices: %v", err)
+	}
+
+	for _, rec := range records {
+		spec := imageSpec(rec.Pool, rec.Name)
+		device, isMapped := mapped[mappedKey(rec.Pool, rec.Name)]
+		if mapped == nil && rec.MappedDevicePath != "" {
+			if _, err := os.Stat(rec.MappedDevicePath); err == nil {
+				device, isMapped = rec.MappedDevicePath, true
+			}
+		}
+
+		if rec.UsedCount <= 0 {
+			if isMapped {
+				unmapCephVolume(spec, device)
+			}
+			continue
+		}
+
+		if !isMapped {
+			// a failed mapping is retried when the volume is mounted
+			device, _ = mapCephVolume(spec)
+		} else if device != rec.MappedDevicePath {
+			logrus.Infof("Ceph volume %s moved from %s to %s", spec, rec.MappedDevicePath, device)
+		}
+
+		r.volumes[rec.Name] = &Volume{
+			driverName:       r.Name(),
+			name:             rec.Name,
+			pool:             rec.Pool,
+			opts:             rec.Opts,
+			mappedDevicePath: device,
+			usedCount:        rec.UsedCount,
+		}
+	}
+	return r.saveState()
+}
+
+// mappedKey returns the key of an image in the map returned by showMapped.
+func mappedKey(pool, name string) string {
+	if pool == "" {
+		pool = defaultPool
+	}
+	return pool + "/" + name
+}
+
+// showMapped returns the device of every RBD image mapped on the host,
+// keyed by mappedKey.
+func showMapped() (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("rbd", "showmapped", "--format", "json")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s - %s", err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	images, err := parseShowMapped(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := make(map[string]string)
+	for _, img := range images {
+		if img.Snap != "" && img.Snap != "-" {
+			continue
+		}
+		mapped[mappedKey(img.Pool, img.Name)] = img.Device
+	}
+	return mapped, nil
+}
+
+// parseShowMapped decodes the JSON output of `rbd showmapped`, which is an
+// object keyed by device id in older releases and a list in newer ones.
+func parseShowMapped(b []byte) ([]mappedImage, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	var images []mappedImage
+	if b[0] == '[' {
+		if err := json.Unmarshal(b, &images); err != nil {
+			return nil, err
+		}
+		return images, nil
+	}
+
+	var byID map[string]mappedImage
+	if err := json.Unmarshal(b, &byID); err != nil {
+		return nil, err
+	}
+	for _, img := range byID {
+		images = append(images, img)
+	}
+	return images, nil
+}
@@ -0,0 +1,81 @@
+package cephvolumedriver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseShowMapped(t *testing.T) {
+	for _, out := range []string{
+		`{"0":{"pool":"rbd","name":"db","snap":"-","device":"/dev/rbd0"}}`,
+		`[{"id":"0","pool":"rbd","namespace":"","name":"db","snap":"-","device":"/dev/rbd0"}]`,
+	} {
+		images, err := parseShowMapped([]byte(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(images) != 1 || images[0].Name != "db" || images[0].Device != "/dev/rbd0" {
+			t.Fatalf("Unexpected images parsed from %s: %v", out, images)
+		}
+	}
+
+	if images, err := parseShowMapped([]byte("\n")); err != nil || len(images) != 0 {
+		t.Fatalf("Expected no images for empty output, got %v, %v", images, err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "ceph-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls, _ := r.List(); len(ls) != 0 {
+		t.Fatalf("Expected no volumes, got %v", ls)
+	}
+
+	records := []volumeRecord{
+		{Name: "inuse", Pool: "fast", Opts: map[string]string{"pool": "fast"}, UsedCount: 1},
+		{Name: "removed", UsedCount: 0},
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(rootDir, volumesPathName, stateFileName), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = New(rootDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, _ := r.List()
+	if len(ls) != 1 || ls[0].Name() != "inuse" {
+		t.Fatalf("Expected only volume inuse to be restored, got %v", ls)
+	}
+	v := r.volumes["inuse"]
+	if v.pool != "fast" || v.usedCount != 1 || v.opts["pool"] != "fast" {
+		t.Fatalf("Volume restored with the wrong state: %+v", v)
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(rootDir, volumesPathName, stateFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records = nil
+	if err := json.Unmarshal(b, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected the removed volume to be dropped from the state, got %v", records)
+	}
+}
@@ -0,0 +1,40 @@
+package cephvolumedriver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// diskUsage is the output of `rbd du`.
+type diskUsage struct {
+	Images []struct {
+		Name     string `json:"name"`
+		Snapshot string `json:"snapshot"`
+		UsedSize int64  `json:"used_size"`
+	} `json:"images"`
+}
+
+// Usage returns the space allocated to the RBD image in the cluster,
+// not counting its snapshots.
+func (v *Volume) Usage() (int64, error) {
+	out, err := runRbd("du", imageSpec(v.pool, v.name), "--format", "json")
+	if err != nil {
+		return -1, err
+	}
+	return parseDiskUsage(out, v.name)
+}
+
+// parseDiskUsage returns the used size of the named image from the JSON
+// output of `rbd du`.
+func parseDiskUsage(b []byte, name string) (int64, error) {
+	var du diskUsage
+	if err := json.Unmarshal(b, &du); err != nil {
+		return -1, err
+	}
+	for _, img := range du.Images {
+		if img.Name == name && img.Snapshot == "" {
+			return img.UsedSize, nil
+		}
+	}
+	return -1, fmt.Errorf("rbd du: no usage reported for image %s", name)
+}
@@ -0,0 +1,22 @@
+package cephvolumedriver
+
+import "testing"
+
+func TestParseDiskUsage(t *testing.T) {
+	out := []byte(`{"images":[` +
+		`{"name":"db","snapshot":"before-upgrade","provisioned_size":1073741824,"used_size":41943040},` +
+		`{"name":"db","provisioned_size":1073741824,"used_size":125829120}],` +
+		`"total_provisioned_size":1073741824,"total_used_size":167772160}`)
+
+	size, err := parseDiskUsage(out, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 125829120 {
+		t.Fatalf("Expected a used size of 125829120, got %d", size)
+	}
+
+	if _, err := parseDiskUsage(out, "other"); err == nil {
+		t.Fatal("Expected an error for an image that is not listed")
+	}
+}
@@ -1,10 +1,34 @@
 package volumedrivers
 
-import "github.com/sara-nl/docker-1.9.1/volume"
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/sara-nl/docker-1.9.1/pkg/plugins"
+	"github.com/sara-nl/docker-1.9.1/volume"
+)
 
 type volumeDriverAdapter struct {
 	name  string
 	proxy *volumeDriverProxy
+
+	// capabilities are fetched from the plugin the first time they are needed
+	capabilitiesOnce sync.Once
+	capabilities     driverCapabilities
+
+	// m protects noList and noGet, which are set once the plugin answered
+	// that it doesn't implement List or Get, so that it isn't asked again
+	m      sync.Mutex
+	noList bool
+	noGet  bool
+}
+
+// driverCapabilities are the capabilities reported by a volume plugin.
+type driverCapabilities struct {
+	// Scope is volume.GlobalScope if the volumes of the plugin are shared
+	// by hosts, and volume.LocalScope otherwise
+	Scope string
 }
 
 func (a *volumeDriverAdapter) Name() string {
@@ -26,11 +50,91 @@ func (a *volumeDriverAdapter) Remove(v volume.Volume) error {
 	return a.proxy.Remove(v.Name())
 }
 
+func (a *volumeDriverAdapter) List() ([]volume.Volume, error) {
+	a.m.Lock()
+	noList := a.noList
+	a.m.Unlock()
+	if noList {
+		return nil, nil
+	}
+
+	ls, err := a.proxy.List()
+	if err != nil {
+		if plugins.IsNotImplemented(err) {
+			// plugins written before List was added to the protocol
+			// don't list their volumes
+			logrus.Debugf("Volume driver %s doesn't list its volumes: %v", a.name, err)
+			a.m.Lock()
+			a.noList = true
+			a.m.Unlock()
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var out []volume.Volume
+	for _, vp := range ls {
+		out = append(out, a.newVolume(vp))
+	}
+	return out, nil
+}
+
+func (a *volumeDriverAdapter) Get(name string) (volume.Volume, error) {
+	a.m.Lock()
+	noGet := a.noGet
+	a.m.Unlock()
+	if noGet {
+		return nil, fmt.Errorf("volume driver %s doesn't support looking up volumes", a.name)
+	}
+
+	v, err := a.proxy.Get(name)
+	if err != nil {
+		if plugins.IsNotImplemented(err) {
+			a.m.Lock()
+			a.noGet = true
+			a.m.Unlock()
+		}
+		return nil, err
+	}
+	if v == nil {
+		// plugins may answer with an empty response for unknown volumes
+		return nil, fmt.Errorf("no such volume %s in volume driver %s", name, a.name)
+	}
+	return a.newVolume(v), nil
+}
+
+// Scope returns the scope reported by the plugin. Plugins that don't
+// implement the Capabilities call have the local scope.
+func (a *volumeDriverAdapter) Scope() string {
+	a.capabilitiesOnce.Do(func() {
+		c, err := a.proxy.Capabilities()
+		if err != nil {
+			logrus.Debugf("Volume driver %s doesn't report its capabilities, assuming local scope: %v", a.name, err)
+		}
+		if c.Scope != volume.GlobalScope {
+			c.Scope = volume.LocalScope
+		}
+		a.capabilities = c
+	})
+	return a.capabilities.Scope
+}
+
+func (a *volumeDriverAdapter) newVolume(v *proxyVolume) *volumeAdapter {
+	return &volumeAdapter{
+		proxy:      a.proxy,
+		name:       v.Name,
+		driverName: a.name,
+		eMount:     v.Mountpoint,
+	}
+}
+
 type volumeAdapter struct {
 	proxy      *volumeDriverProxy
 	name       string
 	driverName string
 	eMount     string // ephemeral host volume path
+	fsType     string // filesystem type of eMount, if it is not bind mounted
+	mountOpts  string
 }
 
 type proxyVolume struct {
@@ -54,12 +158,16 @@ func (a *volumeAdapter) Path() string {
 	return m
 }
 
-func (a *volumeAdapter) Mount() (string, error) {
+func (a *volumeAdapter) Mount(id, containerID string) (string, error) {
 	var err error
-	a.eMount, err = a.proxy.Mount(a.name)
+	a.eMount, a.fsType, a.mountOpts, err = a.proxy.Mount(a.name, id, containerID)
 	return a.eMount, err
 }
 
-func (a *volumeAdapter) Unmount() error {
-	return a.proxy.Unmount(a.name)
+func (a *volumeAdapter) MountType() (string, string) {
+	return a.fsType, a.mountOpts
+}
+
+func (a *volumeAdapter) Unmount(id, containerID string) error {
+	return a.proxy.Unmount(a.name, id, containerID)
 }
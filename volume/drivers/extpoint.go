@@ -18,10 +18,11 @@ var drivers = &driverExtpoint{extensions: make(map[string]volume.Driver)}
 // NewVolumeDriver returns a driver has the given name mapped on the given client.
 func NewVolumeDriver(name string, c client) volume.Driver {
 	proxy := &volumeDriverProxy{c}
-	return &volumeDriverAdapter{name, proxy}
+	return &volumeDriverAdapter{name: name, proxy: proxy}
 }
 
 type opts map[string]string
+type list []*proxyVolume
 
 // volumeDriver defines the available functions that volume plugins must implement.
 // This interface is only defined to generate the proxy objects.
@@ -33,10 +34,18 @@ type volumeDriver interface {
 	Remove(name string) (err error)
 	// Get the mountpoint of the given volume
 	Path(name string) (mountpoint string, err error)
-	// Mount the given volume and return the mountpoint
-	Mount(name string) (mountpoint string, err error)
-	// Unmount the given volume
-	Unmount(name string) (err error)
+	// Mount the given volume for the mount with the given id and return
+	// the mountpoint, along with the filesystem type and options if the
+	// mountpoint is a device that must be mounted rather than bind mounted
+	Mount(name string, id string, containerID string) (mountpoint string, fstype string, mountopts string, err error)
+	// Unmount the given volume for the mount with the given id
+	Unmount(name string, id string, containerID string) (err error)
+	// List lists all the volumes known to the driver
+	List() (volumes list, err error)
+	// Get retrieves the volume with the requested name
+	Get(name string) (volume *proxyVolume, err error)
+	// Capabilities gets the capabilities of the driver
+	Capabilities() (capabilities driverCapabilities, err error)
 }
 
 type driverExtpoint struct {
@@ -98,6 +107,31 @@ func Lookup(name string) (volume.Driver, error) {
 	return d, nil
 }
 
+// GetAllDrivers lists all the registered drivers, along with the
+// VolumeDriver plugins installed on the host, which are looked up if they
+// were not used yet. Plugins that are not running are skipped rather than
+// waited for.
+func GetAllDrivers() ([]volume.Driver, error) {
+	pls, err := plugins.GetAll("VolumeDriver")
+	if err != nil {
+		return nil, err
+	}
+
+	drivers.Lock()
+	defer drivers.Unlock()
+	for _, p := range pls {
+		if _, exists := drivers.extensions[p.Name]; !exists {
+			drivers.extensions[p.Name] = NewVolumeDriver(p.Name, p.Client)
+		}
+	}
+
+	ds := make([]volume.Driver, 0, len(drivers.extensions))
+	for _, d := range drivers.extensions {
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
 // GetDriver returns a volume driver by it's name.
 // If the driver is empty, it looks for the local driver.
 func GetDriver(name string) (volume.Driver, error) {
@@ -96,27 +96,37 @@ func (pp *volumeDriverProxy) Path(name string) (mountpoint string, err error) {
 }
 
 type volumeDriverProxyMountRequest struct {
-	Name string
+	Name        string
+	ID          string
+	ContainerID string
 }
 
 type volumeDriverProxyMountResponse struct {
 	Mountpoint string
+	Fstype     string
+	Mountopts  string
 	Err        string
 }
 
-func (pp *volumeDriverProxy) Mount(name string) (mountpoint string, err error) {
+func (pp *volumeDriverProxy) Mount(name string, id string, containerID string) (mountpoint string, fstype string, mountopts string, err error) {
 	var (
 		req volumeDriverProxyMountRequest
 		ret volumeDriverProxyMountResponse
 	)
 
 	req.Name = name
+	req.ID = id
+	req.ContainerID = containerID
 	if err = pp.Call("VolumeDriver.Mount", req, &ret); err != nil {
 		return
 	}
 
 	mountpoint = ret.Mountpoint
 
+	fstype = ret.Fstype
+
+	mountopts = ret.Mountopts
+
 	if ret.Err != "" {
 		err = errors.New(ret.Err)
 	}
@@ -125,20 +135,24 @@ func (pp *volumeDriverProxy) Mount(name string) (mountpoint string, err error) {
 }
 
 type volumeDriverProxyUnmountRequest struct {
-	Name string
+	Name        string
+	ID          string
+	ContainerID string
 }
 
 type volumeDriverProxyUnmountResponse struct {
 	Err string
 }
 
-func (pp *volumeDriverProxy) Unmount(name string) (err error) {
+func (pp *volumeDriverProxy) Unmount(name string, id string, containerID string) (err error) {
 	var (
 		req volumeDriverProxyUnmountRequest
 		ret volumeDriverProxyUnmountResponse
 	)
 
 	req.Name = name
+	req.ID = id
+	req.ContainerID = containerID
 	if err = pp.Call("VolumeDriver.Unmount", req, &ret); err != nil {
 		return
 	}
@@ -149,3 +163,86 @@ func (pp *volumeDriverProxy) Unmount(name string) (err error) {
 
 	return
 }
+
+type volumeDriverProxyListRequest struct {
+}
+
+type volumeDriverProxyListResponse struct {
+	Volumes list
+	Err     string
+}
+
+func (pp *volumeDriverProxy) List() (volumes list, err error) {
+	var (
+		req volumeDriverProxyListRequest
+		ret volumeDriverProxyListResponse
+	)
+
+	if err = pp.Call("VolumeDriver.List", req, &ret); err != nil {
+		return
+	}
+
+	volumes = ret.Volumes
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyGetRequest struct {
+	Name string
+}
+
+type volumeDriverProxyGetResponse struct {
+	Volume *proxyVolume
+	Err    string
+}
+
+func (pp *volumeDriverProxy) Get(name string) (volume *proxyVolume, err error) {
+	var (
+		req volumeDriverProxyGetRequest
+		ret volumeDriverProxyGetResponse
+	)
+
+	req.Name = name
+	if err = pp.Call("VolumeDriver.Get", req, &ret); err != nil {
+		return
+	}
+
+	volume = ret.Volume
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyCapabilitiesRequest struct {
+}
+
+type volumeDriverProxyCapabilitiesResponse struct {
+	Capabilities driverCapabilities
+	Err          string
+}
+
+func (pp *volumeDriverProxy) Capabilities() (capabilities driverCapabilities, err error) {
+	var (
+		req volumeDriverProxyCapabilitiesRequest
+		ret volumeDriverProxyCapabilitiesResponse
+	)
+
+	if err = pp.Call("VolumeDriver.Capabilities", req, &ret); err != nil {
+		return
+	}
+
+	capabilities = ret.Capabilities
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
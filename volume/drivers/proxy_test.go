@@ -1,6 +1,7 @@
 package volumedrivers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/sara-nl/docker-1.9.1/pkg/plugins"
 	"github.com/sara-nl/docker-1.9.1/pkg/tlsconfig"
+	"github.com/sara-nl/docker-1.9.1/volume"
 )
 
 func TestVolumeRequestError(t *testing.T) {
@@ -58,7 +60,7 @@ func TestVolumeRequestError(t *testing.T) {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 
-	_, err = driver.Mount("volume")
+	_, _, _, err = driver.Mount("volume", "123", "abc")
 	if err == nil {
 		t.Fatal("Expected error, was nil")
 	}
@@ -67,7 +69,7 @@ func TestVolumeRequestError(t *testing.T) {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 
-	err = driver.Unmount("volume")
+	err = driver.Unmount("volume", "123", "abc")
 	if err == nil {
 		t.Fatal("Expected error, was nil")
 	}
@@ -94,3 +96,157 @@ func TestVolumeRequestError(t *testing.T) {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 }
+
+func TestVolumeMountType(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/VolumeDriver.Mount", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Mountpoint": "/dev/sdb", "FsType": "xfs", "MountOpts": "noatime"}`)
+	})
+
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &volumeAdapter{proxy: &volumeDriverProxy{client}, name: "volume", driverName: "fake"}
+	mountpoint, err := v.Mount("123", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mountpoint != "/dev/sdb" {
+		t.Fatalf("Expected mountpoint /dev/sdb, got %s", mountpoint)
+	}
+	if fsType, opts := v.MountType(); fsType != "xfs" || opts != "noatime" {
+		t.Fatalf("Expected mount type xfs with options noatime, got %s with %s", fsType, opts)
+	}
+}
+
+func TestVolumeDriverListGetCapabilities(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/VolumeDriver.List", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Volumes": [{"Name": "vol1", "Mountpoint": "/data/vol1"}, {"Name": "vol2"}]}`)
+	})
+
+	mux.HandleFunc("/VolumeDriver.Get", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Volume": {"Name": "vol1", "Mountpoint": "/data/vol1"}}`)
+	})
+
+	mux.HandleFunc("/VolumeDriver.Capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Capabilities": {"Scope": "global"}}`)
+	})
+
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewVolumeDriver("fake", client)
+	ls, err := d.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ls) != 2 || ls[0].Name() != "vol1" || ls[0].Path() != "/data/vol1" || ls[1].DriverName() != "fake" {
+		t.Fatalf("Unexpected volumes: %v", ls)
+	}
+
+	v, err := d.Get("vol1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name() != "vol1" {
+		t.Fatalf("Expected volume vol1, got %s", v.Name())
+	}
+
+	if scope := d.Scope(); scope != volume.GlobalScope {
+		t.Fatalf("Expected global scope, got %s", scope)
+	}
+}
+
+func TestVolumeDriverListGetNotImplemented(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	calls := make(map[string]int)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		http.NotFound(w, r)
+	})
+
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewVolumeDriver("fake", client)
+	for i := 0; i < 2; i++ {
+		ls, err := d.List()
+		if err != nil {
+			t.Fatalf("Expected a driver without List to have no volumes, got %v", err)
+		}
+		if len(ls) != 0 {
+			t.Fatalf("Unexpected volumes: %v", ls)
+		}
+		if _, err := d.Get("vol1"); err == nil {
+			t.Fatal("Expected an error looking up a volume in a driver without Get")
+		}
+	}
+	if calls["/VolumeDriver.List"] != 1 || calls["/VolumeDriver.Get"] != 1 {
+		t.Fatalf("Expected the plugin to be asked once whether it implements List and Get, got %v", calls)
+	}
+}
+
+func TestVolumeMountIDs(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var requests []map[string]string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, req)
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Mountpoint": "/data/volume"}`)
+	}
+	mux.HandleFunc("/VolumeDriver.Mount", handler)
+	mux.HandleFunc("/VolumeDriver.Unmount", handler)
+
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &volumeAdapter{proxy: &volumeDriverProxy{client}, name: "volume", driverName: "fake"}
+	if _, err := v.Mount("123", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unmount("123", "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	for _, req := range requests {
+		if req["Name"] != "volume" || req["ID"] != "123" || req["ContainerID"] != "abc" {
+			t.Fatalf("Unexpected request: %v", req)
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package local
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -11,8 +12,12 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/Sirupsen/logrus"
+
 	derr "github.com/sara-nl/docker-1.9.1/errors"
+	"github.com/sara-nl/docker-1.9.1/pkg/directory"
 	"github.com/sara-nl/docker-1.9.1/pkg/idtools"
+	"github.com/sara-nl/docker-1.9.1/pkg/units"
 	"github.com/sara-nl/docker-1.9.1/utils"
 	"github.com/sara-nl/docker-1.9.1/volume"
 )
@@ -23,6 +28,9 @@ import (
 const (
 	VolumeDataPathName = "_data"
 	volumesPathName    = "volumes"
+	optsFileName       = "opts.json"
+
+	optSize = "size"
 )
 
 var (
@@ -59,11 +67,15 @@ func New(scope string, rootUID, rootGID int) (*Root, error) {
 
 	for _, d := range dirs {
 		name := filepath.Base(d.Name())
-		r.volumes[name] = &localVolume{
+		v := &localVolume{
 			driverName: r.Name(),
 			name:       name,
 			path:       r.DataPath(name),
 		}
+		if err := v.loadOpts(); err != nil {
+			return nil, err
+		}
+		r.volumes[name] = v
 	}
 
 	return r, nil
@@ -79,15 +91,26 @@ type Root struct {
 	volumes map[string]*localVolume
 	rootUID int
 	rootGID int
+	// quota sets the size limits of volumes, it is only set up once a
+	// volume with a size is created
+	quota *quotaControl
 }
 
 // List lists all the volumes
-func (r *Root) List() []volume.Volume {
+func (r *Root) List() ([]volume.Volume, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
 	var ls []volume.Volume
 	for _, v := range r.volumes {
 		ls = append(ls, v)
 	}
-	return ls
+	return ls, nil
+}
+
+// Scope returns the scope of the driver, local volumes only exist on
+// this host.
+func (r *Root) Scope() string {
+	return volume.LocalScope
 }
 
 // DataPath returns the constructed path of this volume.
@@ -102,11 +125,15 @@ func (r *Root) Name() string {
 
 // Create creates a new volume.Volume with the provided name, creating
 // the underlying directory tree required for this volume in the
-// process.
-func (r *Root) Create(name string, _ map[string]string) (volume.Volume, error) {
+// process. The size option limits the disk space the volume may use.
+func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error) {
 	if err := r.validateName(name); err != nil {
 		return nil, err
 	}
+	size, err := r.parseOpts(opts)
+	if err != nil {
+		return nil, err
+	}
 
 	r.m.Lock()
 	defer r.m.Unlock()
@@ -116,6 +143,14 @@ func (r *Root) Create(name string, _ map[string]string) (volume.Volume, error) {
 		return v, nil
 	}
 
+	if size > 0 && r.quota == nil {
+		q, err := newQuotaControl(r.path)
+		if err != nil {
+			return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(opts[optSize], optSize, r.Name(), err)
+		}
+		r.quota = q
+	}
+
 	path := r.DataPath(name)
 	if err := idtools.MkdirAllAs(path, 0755, r.rootUID, r.rootGID); err != nil {
 		if os.IsExist(err) {
@@ -128,10 +163,54 @@ func (r *Root) Create(name string, _ map[string]string) (volume.Volume, error) {
 		name:       name,
 		path:       path,
 	}
+	if size > 0 {
+		if err := r.setQuota(v, size); err != nil {
+			removePath(filepath.Dir(path))
+			return nil, err
+		}
+	}
 	r.volumes[name] = v
 	return v, nil
 }
 
+// parseOpts validates the driver options of a volume and returns its size,
+// zero if the volume is not limited.
+func (r *Root) parseOpts(opts map[string]string) (int64, error) {
+	var size int64
+	for key, val := range opts {
+		switch key {
+		case optSize:
+			var err error
+			if size, err = units.RAMInBytes(val); err != nil {
+				return 0, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, r.Name(), err)
+			}
+			if size <= 0 {
+				return 0, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, r.Name(), "the size must be larger than zero")
+			}
+		default:
+			return 0, derr.ErrorCodeVolumeOptUnknown.WithArgs(key, r.Name())
+		}
+	}
+	return size, nil
+}
+
+// setQuota limits the size of a new volume with a project quota, and
+// records the limit next to the data of the volume. It must be called with
+// r.m held, once r.quota is set up.
+func (r *Root) setQuota(v *localVolume, size int64) error {
+	projectID := uint32(quotaProjectBase)
+	for _, lv := range r.volumes {
+		if lv.projectID >= projectID {
+			projectID = lv.projectID + 1
+		}
+	}
+	if err := r.quota.setQuota(v.path, projectID, size); err != nil {
+		return err
+	}
+	v.size, v.projectID = size, projectID
+	return v.saveOpts()
+}
+
 // Remove removes the specified volume and all underlying data. If the
 // given volume does not belong to this driver and an error is
 // returned. The volume is reference counted, if all references are
@@ -157,6 +236,12 @@ func (r *Root) Remove(v volume.Volume) error {
 		return fmt.Errorf("Unable to remove a directory of out the Docker root %s: %s", r.scope, realPath)
 	}
 
+	if lv.projectID != 0 {
+		if err := r.clearQuota(lv); err != nil {
+			logrus.Warnf("Failed to clear the quota of volume %s: %v", lv.name, err)
+		}
+	}
+
 	if err := removePath(realPath); err != nil {
 		return err
 	}
@@ -193,6 +278,19 @@ func (r *Root) validateName(name string) error {
 	return nil
 }
 
+// clearQuota removes the limit on the project of a volume, so the project
+// can be reused. It must be called with r.m held.
+func (r *Root) clearQuota(v *localVolume) error {
+	if r.quota == nil {
+		q, err := newQuotaControl(r.path)
+		if err != nil {
+			return err
+		}
+		r.quota = q
+	}
+	return r.quota.clearQuota(v.projectID)
+}
+
 // localVolume implements the Volume interface from the volume package and
 // represents the volumes created by Root.
 type localVolume struct {
@@ -204,6 +302,46 @@ type localVolume struct {
 	path string
 	// driverName is the name of the driver that created the volume.
 	driverName string
+	// size is the disk space the volume may use, zero if it is not limited
+	size int64
+	// projectID is the quota project the data of the volume belongs to
+	projectID uint32
+}
+
+// volumeOpts is the on-disk representation of the options a volume was
+// created with.
+type volumeOpts struct {
+	Size      int64
+	ProjectID uint32
+}
+
+func (v *localVolume) optsPath() string {
+	return filepath.Join(filepath.Dir(v.path), optsFileName)
+}
+
+// loadOpts reads the options of a volume created by a previous daemon.
+func (v *localVolume) loadOpts() error {
+	b, err := ioutil.ReadFile(v.optsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var opts volumeOpts
+	if err := json.Unmarshal(b, &opts); err != nil {
+		return fmt.Errorf("Error reading the options of volume %s: %v", v.name, err)
+	}
+	v.size, v.projectID = opts.Size, opts.ProjectID
+	return nil
+}
+
+func (v *localVolume) saveOpts() error {
+	b, err := json.Marshal(volumeOpts{Size: v.size, ProjectID: v.projectID})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(v.optsPath(), b, 0600)
 }
 
 // Name returns the name of the given Volume.
@@ -222,11 +360,22 @@ func (v *localVolume) Path() string {
 }
 
 // Mount implements the localVolume interface, returning the data location.
-func (v *localVolume) Mount() (string, error) {
+func (v *localVolume) Mount(id, containerID string) (string, error) {
 	return v.path, nil
 }
 
 // Umount is for satisfying the localVolume interface and does not do anything in this driver.
-func (v *localVolume) Unmount() error {
+func (v *localVolume) Unmount(id, containerID string) error {
 	return nil
 }
+
+// Usage returns the disk space used by the data of the volume.
+func (v *localVolume) Usage() (int64, error) {
+	return directory.Size(v.path)
+}
+
+// Capacity returns the size limit of the volume, zero if the volume is
+// not limited.
+func (v *localVolume) Capacity() (int64, error) {
+	return v.size, nil
+}
@@ -43,7 +43,7 @@ func TestRemove(t *testing.T) {
 		t.Fatal("volume dir not removed")
 	}
 
-	if len(r.List()) != 0 {
+	if l, _ := r.List(); len(l) != 0 {
 		t.Fatal("expected there to be no volumes")
 	}
 }
@@ -124,3 +124,67 @@ func TestCreate(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateWithInvalidOpts(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []map[string]string{
+		{"size": "lots"},
+		{"size": "0"},
+		{"unknown": "value"},
+	} {
+		if _, err := r.Create("testing", opts); err == nil {
+			t.Fatalf("Expected an error for options %v", opts)
+		}
+	}
+	if l, _ := r.List(); len(l) != 0 {
+		t.Fatal("expected there to be no volumes")
+	}
+}
+
+func TestInitializeWithSize(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vol, err := r.Create("testing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lv := vol.(*localVolume)
+	lv.size, lv.projectID = 10*1024*1024, quotaProjectBase
+	if err := lv.saveOpts(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Get(vol.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacity, _ := v.(*localVolume).Capacity(); capacity != 10*1024*1024 {
+		t.Fatalf("Expected a capacity of 10MB, got %d", capacity)
+	}
+	if v.(*localVolume).projectID != quotaProjectBase {
+		t.Fatalf("Expected project %d, got %d", quotaProjectBase, v.(*localVolume).projectID)
+	}
+}
@@ -0,0 +1,101 @@
+// +build linux
+
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
+)
+
+// quotaProjectBase is the first XFS project id given to volumes. The
+// lower ids are left to the projects an administrator sets up.
+const quotaProjectBase = 100000
+
+// quotaControl limits the size of volume directories with XFS project
+// quotas, set with xfs_quota(8).
+type quotaControl struct {
+	// mountpoint is the mount point of the filesystem the volumes are on
+	mountpoint string
+}
+
+// newQuotaControl returns the quotaControl of the filesystem the given
+// directory is on, which must be an XFS filesystem mounted with project
+// quotas enabled.
+func newQuotaControl(path string) (*quotaControl, error) {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := mount.GetMounts()
+	if err != nil {
+		return nil, err
+	}
+	fs := findFilesystem(realPath, mounts)
+	if fs == nil {
+		return nil, fmt.Errorf("unable to find the filesystem of %s", realPath)
+	}
+	if fs.Fstype != "xfs" {
+		return nil, fmt.Errorf("size limits require %s to be on an XFS filesystem, it is on %s", realPath, fs.Fstype)
+	}
+	if !hasProjectQuota(fs.VfsOpts) {
+		return nil, fmt.Errorf("size limits require %s to be mounted with the pquota option", fs.Mountpoint)
+	}
+	return &quotaControl{mountpoint: fs.Mountpoint}, nil
+}
+
+// findFilesystem returns the mount the given path is on.
+func findFilesystem(path string, mounts []*mount.Info) *mount.Info {
+	var fs *mount.Info
+	for _, m := range mounts {
+		if m.Mountpoint != "/" && path != m.Mountpoint && !strings.HasPrefix(path, m.Mountpoint+"/") {
+			continue
+		}
+		if fs == nil || len(m.Mountpoint) >= len(fs.Mountpoint) {
+			// the last of the mounts on the same mount point hides the others
+			fs = m
+		}
+	}
+	return fs
+}
+
+// hasProjectQuota returns whether the superblock options of an XFS
+// filesystem enforce project quotas.
+func hasProjectQuota(opts string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "prjquota" || opt == "pquota" {
+			return true
+		}
+	}
+	return false
+}
+
+// setQuota makes the directory the root of the given project, and limits
+// the disk space of the project to size bytes.
+func (q *quotaControl) setQuota(path string, projectID uint32, size int64) error {
+	// -s marks the directory so that everything created in it inherits
+	// the project
+	if err := q.run(fmt.Sprintf("project -s -p %s %d", path, projectID)); err != nil {
+		return err
+	}
+	return q.run(fmt.Sprintf("limit -p bhard=%dk %d", (size+1023)/1024, projectID))
+}
+
+// clearQuota removes the limit of the given project.
+func (q *quotaControl) clearQuota(projectID uint32) error {
+	return q.run(fmt.Sprintf("limit -p bhard=0 %d", projectID))
+}
+
+func (q *quotaControl) run(command string) error {
+	cmd := exec.Command("xfs_quota", "-x", "-c", command, q.mountpoint)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("xfs_quota -c %q %s: %s - %s", command, q.mountpoint, err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// +build linux
+
+package local
+
+import (
+	"testing"
+
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
+)
+
+func TestFindFilesystem(t *testing.T) {
+	mounts := []*mount.Info{
+		{Mountpoint: "/", Fstype: "ext4"},
+		{Mountpoint: "/var/lib", Fstype: "ext4"},
+		{Mountpoint: "/var/lib/docker", Fstype: "ext4"},
+		{Mountpoint: "/var/lib/docker", Fstype: "xfs", VfsOpts: "rw,attr2,inode64,prjquota"},
+		{Mountpoint: "/var/lib/dockerx", Fstype: "tmpfs"},
+	}
+
+	fs := findFilesystem("/var/lib/docker/volumes", mounts)
+	if fs == nil || fs.Fstype != "xfs" {
+		t.Fatalf("Expected the xfs filesystem on /var/lib/docker, got %v", fs)
+	}
+	if !hasProjectQuota(fs.VfsOpts) {
+		t.Fatalf("Expected project quotas to be enabled by %s", fs.VfsOpts)
+	}
+	if fs := findFilesystem("/srv", mounts); fs == nil || fs.Mountpoint != "/" {
+		t.Fatalf("Expected the root filesystem, got %v", fs)
+	}
+	if hasProjectQuota("rw,attr2,inode64,usrquota") {
+		t.Fatal("Expected project quotas to be disabled")
+	}
+}
@@ -0,0 +1,26 @@
+// +build !linux
+
+package local
+
+import "errors"
+
+// quotaProjectBase is the first quota project id given to volumes.
+const quotaProjectBase = 100000
+
+var errQuotaUnsupported = errors.New("size limits are not supported on this platform")
+
+// quotaControl limits the size of volume directories, which is not
+// supported on this platform.
+type quotaControl struct{}
+
+func newQuotaControl(path string) (*quotaControl, error) {
+	return nil, errQuotaUnsupported
+}
+
+func (q *quotaControl) setQuota(path string, projectID uint32, size int64) error {
+	return errQuotaUnsupported
+}
+
+func (q *quotaControl) clearQuota(projectID uint32) error {
+	return errQuotaUnsupported
+}
@@ -1,43 +1,132 @@
 package nfsvolumedriver
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 
+	"github.com/Sirupsen/logrus"
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
 	"github.com/sara-nl/docker-1.9.1/volume"
 )
 
-func New() *Root {
-	return &Root{
+const (
+	driverName    = "nfs"
+	mountsDirName = "mnt"
+)
+
+// ErrNotFound is the typed error returned when the requested volume name can't be found
+var ErrNotFound = errors.New("volume not found")
+
+// New instantiates a new Root instance that keeps its state under the
+// given scope, and restores the volumes known to a previous daemon.
+func New(scope string) (*Root, error) {
+	rootDirectory := filepath.Join(scope, volumesPathName)
+	if err := os.MkdirAll(filepath.Join(rootDirectory, mountsDirName), 0700); err != nil {
+		return nil, err
+	}
+
+	r := &Root{
+		path:    rootDirectory,
 		volumes: make(map[string]*Volume),
 	}
+	if err := r.restore(); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
 type Root struct {
 	m       sync.Mutex
+	path    string
 	volumes map[string]*Volume
 }
 
+// List lists all the volumes
+func (r *Root) List() ([]volume.Volume, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var ls []volume.Volume
+	for _, v := range r.volumes {
+		ls = append(ls, v)
+	}
+	return ls, nil
+}
+
+// Get looks up the volume with the given name.
+func (r *Root) Get(name string) (volume.Volume, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	v, exists := r.volumes[name]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+// Scope returns the scope of the driver. The share a volume is backed by
+// is given when the volume is created, so a volume name only has a
+// meaning on this host.
+func (r *Root) Scope() string {
+	return volume.LocalScope
+}
+
 func (r *Root) Name() string {
-	return "nfs"
+	return driverName
 }
 
-func (r *Root) Create(name string, _ map[string]string) (volume.Volume, error) {
+func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error) {
 	r.m.Lock()
 	defer r.m.Unlock()
 
 	v, exists := r.volumes[name]
 	if !exists {
-		v = &Volume{
-			driverName:       r.Name(),
-			name:             name,
+		var err error
+		v, err = r.newVolume(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(v.path, 0755); err != nil {
+			return nil, err
 		}
 		r.volumes[name] = v
 	}
 	v.use()
+	if err := r.saveState(); err != nil {
+		logrus.Errorf("Failed to save the state of NFS volume %s: %v", name, err)
+	}
 	return v, nil
 }
 
+// newVolume validates the options of a volume and returns the volume
+// they describe.
+func (r *Root) newVolume(name string, opts map[string]string) (*Volume, error) {
+	o, err := parseMountOptions(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Volume{
+		driverName: r.Name(),
+		name:       name,
+		opts:       opts,
+		source:     o.source(),
+		mountOpts:  o.mountOpts(),
+		path:       r.mountPath(name),
+	}, nil
+}
+
+// mountPath returns the directory the volume with the given name is
+// mounted on. Volume names may contain slashes, so the name is hashed.
+func (r *Root) mountPath(name string) string {
+	return filepath.Join(r.path, mountsDirName, fmt.Sprintf("%x", sha256.Sum256([]byte(name))))
+}
+
 func (r *Root) Remove(v volume.Volume) error {
 	r.m.Lock()
 	defer r.m.Unlock()
@@ -47,19 +136,32 @@ func (r *Root) Remove(v volume.Volume) error {
 		return errors.New("unknown volume type")
 	}
 	lv.release()
-	if lv.usedCount == 0 {
+	if lv.usedCount <= 0 {
+		if err := lv.forceUnmount(); err != nil {
+			return err
+		}
 		delete(r.volumes, lv.name)
 	}
-	return nil
+	return r.saveState()
 }
 
 type Volume struct {
 	m         sync.Mutex
 	usedCount int
+	// number of active mounts of the export on the host
+	activeMounts int
 	// unique name of the volume
 	name string
 	// driverName is the name of the driver that created the volume.
 	driverName string
+	// the driver options the volume was created with
+	opts map[string]string
+	// the NFS share, as server:/export
+	source string
+	// the options passed to mount(8)
+	mountOpts string
+	// the directory on the host the share is mounted on
+	path string
 }
 
 func (v *Volume) Name() string {
@@ -71,15 +173,72 @@ func (v *Volume) DriverName() string {
 }
 
 func (v *Volume) Path() string {
-	return ""
+	return v.path
+}
+
+// Mount mounts the NFS share on the host, the first time it is called,
+// and returns the directory to bind mount into the container.
+func (v *Volume) Mount(id, containerID string) (string, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.activeMounts == 0 {
+		if err := os.MkdirAll(v.path, 0755); err != nil {
+			return "", err
+		}
+		mounted, err := mount.Mounted(v.path)
+		if err != nil {
+			return "", err
+		}
+		if !mounted {
+			if err := mountNFS(v.source, v.path, v.mountOpts); err != nil {
+				return "", err
+			}
+		}
+	}
+	v.activeMounts++
+	return v.path, nil
 }
 
-func (v *Volume) Mount() (string, error) {
-	// The return value from this method will be passed to the container
-	return v.name, nil
+// Unmount unmounts the NFS share from the host once it is no longer
+// used by any container.
+func (v *Volume) Unmount(id, containerID string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.activeMounts == 0 {
+		return nil
+	}
+	v.activeMounts--
+	if v.activeMounts > 0 {
+		return nil
+	}
+	return unmountNFS(v.source, v.path)
 }
 
-func (v *Volume) Unmount() error {
+// forceUnmount unmounts the NFS share regardless of the number of active
+// mounts and removes the mount point.
+func (v *Volume) forceUnmount() error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	v.activeMounts = 0
+	mounted, err := mount.Mounted(v.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if mounted {
+		if err := unmountNFS(v.source, v.path); err != nil {
+			return err
+		}
+	}
+	// never use RemoveAll here, the share might still be mounted
+	if err := os.Remove(v.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return nil
 }
 
@@ -94,3 +253,28 @@ func (v *Volume) release() {
 	v.usedCount--
 	v.m.Unlock()
 }
+
+func mountNFS(source, target, opts string) error {
+	// Using the mount command rather than the mount syscall because for NFS
+	// mounts the syscall requires us to resolve the server address ourselves
+	args := []string{"-t", "nfs", "-o", opts, source, target}
+	cmd := exec.Command("mount", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := fmt.Sprintf("Failed to mount NFS share %s on %s with options %s: %s - %s", source, target, opts, err, strings.TrimRight(stderr.String(), "\n"))
+		logrus.Error(msg)
+		return errors.New(msg)
+	}
+	logrus.Infof("Succeeded in mounting NFS share %s on %s", source, target)
+	return nil
+}
+
+func unmountNFS(source, target string) error {
+	if err := mount.Unmount(target); err != nil {
+		logrus.Errorf("Failed to unmount NFS share %s from %s: %s", source, target, err)
+		return err
+	}
+	logrus.Infof("Succeeded in unmounting NFS share %s from %s", source, target)
+	return nil
+}
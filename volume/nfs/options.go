@@ -0,0 +1,100 @@
+package nfsvolumedriver
+
+import (
+	"path"
+	"strings"
+
+	derr "github.com/sara-nl/docker-1.9.1/errors"
+)
+
+const (
+	optServer    = "server"
+	optExport    = "export"
+	optVersion   = "nfsvers"
+	optMountOpts = "mountopts"
+
+	// retry=0,timeo=30: fail if the NFS server can't be reached in three
+	// seconds, without retries. This is aggressive, but necessary because
+	// the daemon becomes unresponsive while the mount command hangs.
+	defaultMountOpts = "retry=0,timeo=30"
+)
+
+var nfsVersions = map[string]bool{
+	"3":   true,
+	"4":   true,
+	"4.0": true,
+	"4.1": true,
+	"4.2": true,
+}
+
+// mountOptions describes the NFS share a volume is backed by.
+type mountOptions struct {
+	Server    string
+	Export    string
+	Version   string
+	MountOpts string
+}
+
+// parseMountOptions validates the driver options of a volume. When neither
+// a server nor an export is given, the volume name is parsed as a share in
+// the SERVER//EXPORT form used by `-v SERVER//EXPORT:/dest:nfs`.
+func parseMountOptions(name string, opts map[string]string) (*mountOptions, error) {
+	o := &mountOptions{}
+	for key, val := range opts {
+		switch key {
+		case optServer:
+			if val == "" || strings.ContainsAny(val, " /,") {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "expected a host name or address")
+			}
+			o.Server = val
+		case optExport:
+			if !path.IsAbs(val) {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "the export must be an absolute path")
+			}
+			o.Export = path.Clean(val)
+		case optVersion:
+			if !nfsVersions[val] {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "supported versions are 3, 4, 4.0, 4.1 and 4.2")
+			}
+			o.Version = val
+		case optMountOpts:
+			if strings.ContainsAny(val, " \t\n") {
+				return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs(val, key, driverName, "mount options must be a comma separated list")
+			}
+			o.MountOpts = val
+		default:
+			return nil, derr.ErrorCodeVolumeOptUnknown.WithArgs(key, driverName)
+		}
+	}
+
+	if o.Server == "" && o.Export == "" {
+		if i := strings.Index(name, "//"); i > 0 {
+			o.Server = name[:i]
+			o.Export = path.Clean("/" + name[i+2:])
+		}
+	}
+	if o.Server == "" {
+		return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs("", optServer, driverName, "an NFS server is required")
+	}
+	if o.Export == "" {
+		return nil, derr.ErrorCodeVolumeOptInvalid.WithArgs("", optExport, driverName, "an export path is required")
+	}
+	return o, nil
+}
+
+// source returns the share in the form expected by mount(8).
+func (o *mountOptions) source() string {
+	return o.Server + ":" + o.Export
+}
+
+// mountOpts returns the options to pass to mount(8).
+func (o *mountOptions) mountOpts() string {
+	opts := defaultMountOpts
+	if o.Version != "" {
+		opts += ",nfsvers=" + o.Version
+	}
+	if o.MountOpts != "" {
+		opts += "," + o.MountOpts
+	}
+	return opts
+}
@@ -0,0 +1,51 @@
+package nfsvolumedriver
+
+import "testing"
+
+func TestParseMountOptions(t *testing.T) {
+	o, err := parseMountOptions("data", map[string]string{
+		"server":    "10.0.0.5",
+		"export":    "/data/",
+		"nfsvers":   "4.1",
+		"mountopts": "soft,noatime",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := o.source(); src != "10.0.0.5:/data" {
+		t.Fatalf("Expected source 10.0.0.5:/data, got %s", src)
+	}
+	if opts := o.mountOpts(); opts != defaultMountOpts+",nfsvers=4.1,soft,noatime" {
+		t.Fatalf("Unexpected mount options %s", opts)
+	}
+}
+
+func TestParseMountOptionsFromName(t *testing.T) {
+	o, err := parseMountOptions("10.0.0.5//data/share", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := o.source(); src != "10.0.0.5:/data/share" {
+		t.Fatalf("Expected source 10.0.0.5:/data/share, got %s", src)
+	}
+	if opts := o.mountOpts(); opts != defaultMountOpts {
+		t.Fatalf("Expected default mount options, got %s", opts)
+	}
+}
+
+func TestParseMountOptionsInvalid(t *testing.T) {
+	for _, opts := range []map[string]string{
+		nil,
+		{"server": "10.0.0.5"},
+		{"export": "/data"},
+		{"server": "10.0.0.5", "export": "data"},
+		{"server": "10.0.0.5/x", "export": "/data"},
+		{"server": "10.0.0.5", "export": "/data", "nfsvers": "2"},
+		{"server": "10.0.0.5", "export": "/data", "mountopts": "soft, noatime"},
+		{"server": "10.0.0.5", "export": "/data", "unknown": "value"},
+	} {
+		if _, err := parseMountOptions("data", opts); err == nil {
+			t.Fatalf("Expected an error for options %v", opts)
+		}
+	}
+}
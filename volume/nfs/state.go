@@ -0,0 +1,91 @@
+package nfsvolumedriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	volumesPathName = "nfs-volumes"
+	stateFileName   = "volumes.json"
+)
+
+// volumeRecord is the on-disk representation of a volume, used to
+// rediscover the volumes after a daemon restart.
+type volumeRecord struct {
+	Name      string
+	Opts      map[string]string `json:",omitempty"`
+	UsedCount int
+}
+
+func (r *Root) statePath() string {
+	return filepath.Join(r.path, stateFileName)
+}
+
+// saveState writes the records of all the known volumes to disk.
+// It must be called with r.m held.
+func (r *Root) saveState() error {
+	records := make([]volumeRecord, 0, len(r.volumes))
+	for _, v := range r.volumes {
+		v.m.Lock()
+		records = append(records, volumeRecord{
+			Name:      v.name,
+			Opts:      v.opts,
+			UsedCount: v.usedCount,
+		})
+		v.m.Unlock()
+	}
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	tmp := r.statePath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.statePath())
+}
+
+// restore loads the volumes persisted by a previous daemon. Records of
+// volumes that were being removed are dropped, and shares left mounted on
+// the host are unmounted; they are mounted again when a container that
+// uses them starts.
+func (r *Root) restore() error {
+	b, err := ioutil.ReadFile(r.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var records []volumeRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return fmt.Errorf("Error reading NFS volume state %s: %v", r.statePath(), err)
+	}
+
+	for _, rec := range records {
+		v, err := r.newVolume(rec.Name, rec.Opts)
+		if err != nil {
+			logrus.Errorf("Dropping NFS volume %s: %v", rec.Name, err)
+			continue
+		}
+		if err := v.forceUnmount(); err != nil {
+			logrus.Errorf("Failed to clean up NFS volume %s: %v", rec.Name, err)
+		}
+		if rec.UsedCount <= 0 {
+			continue
+		}
+		if err := os.MkdirAll(v.path, 0755); err != nil {
+			return err
+		}
+		v.usedCount = rec.UsedCount
+		r.volumes[rec.Name] = v
+	}
+	return r.saveState()
+}
@@ -0,0 +1,30 @@
+package nfsvolumedriver
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/sara-nl/docker-1.9.1/pkg/mount"
+)
+
+// Usage returns the space used on the file system of the NFS export, as
+// reported by the server. The export may be shared with other volumes or
+// hosts. The usage is only known while the share is mounted on the host.
+func (v *Volume) Usage() (int64, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	mounted, err := mount.Mounted(v.path)
+	if err != nil {
+		return -1, err
+	}
+	if !mounted {
+		return -1, fmt.Errorf("NFS share %s is not mounted", v.source)
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(v.path, &st); err != nil {
+		return -1, err
+	}
+	return int64(st.Blocks-st.Bfree) * int64(st.Bsize), nil
+}
@@ -0,0 +1,10 @@
+// +build !linux
+
+package nfsvolumedriver
+
+import "errors"
+
+// Usage is not supported on this platform.
+func (v *Volume) Usage() (int64, error) {
+	return -1, errors.New("NFS volume usage is not supported on this platform")
+}
@@ -1,7 +1,11 @@
 package store
 
 import (
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -16,18 +20,51 @@ var (
 	ErrNoSuchVolume = errors.New("no such volume")
 )
 
+// metadataFileName is the name of the file the store keeps the metadata of
+// the volumes in, relative to the root passed to New.
+const metadataFileName = "volume-metadata.json"
+
 // New initializes a VolumeStore to keep
 // reference counting of volumes in the system.
-func New() *VolumeStore {
-	return &VolumeStore{
-		vols: make(map[string]*volumeCounter),
+// The metadata of the volumes, like their labels, is persisted under
+// rootPath. If rootPath is empty, the metadata is only kept in memory.
+func New(rootPath string) (*VolumeStore, error) {
+	s := &VolumeStore{
+		vols:     make(map[string]*volumeCounter),
+		metadata: make(map[string]*volumeMetadata),
+	}
+	if rootPath == "" {
+		return s, nil
+	}
+
+	s.metadataPath = filepath.Join(rootPath, metadataFileName)
+	b, err := ioutil.ReadFile(s.metadataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
 	}
+	if err := json.Unmarshal(b, &s.metadata); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 // VolumeStore is a struct that stores the list of volumes available and keeps track of their usage counts
 type VolumeStore struct {
 	vols map[string]*volumeCounter
-	mu   sync.Mutex
+	// metadata holds the metadata of the volumes, keyed by volume name
+	metadata map[string]*volumeMetadata
+	// metadataPath is the file the metadata is persisted in, if any
+	metadataPath string
+	mu           sync.Mutex
+}
+
+// volumeMetadata is the information the store keeps about a volume, on top
+// of what the volume driver knows about it
+type volumeMetadata struct {
+	Labels map[string]string `json:",omitempty"`
 }
 
 // volumeCounter keeps track of references to a volume
@@ -43,8 +80,9 @@ func (s *VolumeStore) AddAll(vols []volume.Volume) {
 	}
 }
 
-// Create tries to find an existing volume with the given name or create a new one from the passed in driver
-func (s *VolumeStore) Create(name, driverName string, opts map[string]string) (volume.Volume, error) {
+// Create tries to find an existing volume with the given name or create a new one from the passed in driver.
+// The labels are only set when a new volume is created.
+func (s *VolumeStore) Create(name, driverName string, opts, labels map[string]string) (volume.Volume, error) {
 	s.mu.Lock()
 	if vc, exists := s.vols[name]; exists {
 		v := vc.Volume
@@ -65,21 +103,72 @@ func (s *VolumeStore) Create(name, driverName string, opts map[string]string) (v
 	}
 
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.vols[v.Name()] = &volumeCounter{v, 0}
-	s.mu.Unlock()
+	if len(labels) > 0 {
+		s.metadata[v.Name()] = &volumeMetadata{Labels: labels}
+		if err := s.saveMetadata(); err != nil {
+			logrus.Errorf("Error saving the labels of volume %s: %v", v.Name(), err)
+		}
+	}
 
 	return v, nil
 }
 
-// Get looks if a volume with the given name exists and returns it if so
-func (s *VolumeStore) Get(name string) (volume.Volume, error) {
+// Labels returns the labels of the passed in volume
+func (s *VolumeStore) Labels(v volume.Volume) map[string]string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	vc, exists := s.vols[name]
+	md, exists := s.metadata[v.Name()]
 	if !exists {
+		return nil
+	}
+	labels := make(map[string]string, len(md.Labels))
+	for k, val := range md.Labels {
+		labels[k] = val
+	}
+	return labels
+}
+
+// saveMetadata persists the metadata of the volumes. It must be called with
+// s.mu held.
+func (s *VolumeStore) saveMetadata() error {
+	if s.metadataPath == "" {
+		return nil
+	}
+	b, err := json.Marshal(s.metadata)
+	if err != nil {
+		return err
+	}
+	tmp := s.metadataPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.metadataPath)
+}
+
+// Get looks if a volume with the given name exists and returns it if so.
+// Volumes that are not in the store yet are looked up in the volume drivers.
+func (s *VolumeStore) Get(name string) (volume.Volume, error) {
+	s.mu.Lock()
+	vc, exists := s.vols[name]
+	s.mu.Unlock()
+	if exists {
+		return vc.Volume, nil
+	}
+
+	v, err := getDriverVolume(name)
+	if err != nil {
 		return nil, ErrNoSuchVolume
 	}
-	return vc.Volume, nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vc, exists := s.vols[name]; exists {
+		return vc.Volume, nil
+	}
+	s.vols[name] = &volumeCounter{v, 0}
+	return v, nil
 }
 
 // Remove removes the requested volume. A volume is not removed if the usage count is > 0
@@ -105,6 +194,12 @@ func (s *VolumeStore) Remove(v volume.Volume) error {
 		return err
 	}
 	delete(s.vols, name)
+	if _, exists := s.metadata[name]; exists {
+		delete(s.metadata, name)
+		if err := s.saveMetadata(); err != nil {
+			logrus.Errorf("Error removing the labels of volume %s: %v", name, err)
+		}
+	}
 	return nil
 }
 
@@ -122,6 +217,24 @@ func (s *VolumeStore) Increment(v volume.Volume) {
 	vc.count++
 }
 
+// IncrementIfUnused increments the usage count of the passed in volume by 1
+// if it is not in use, and returns ErrVolumeInUse otherwise.
+func (s *VolumeStore) IncrementIfUnused(v volume.Volume) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vc, exists := s.vols[v.Name()]
+	if !exists {
+		s.vols[v.Name()] = &volumeCounter{v, 1}
+		return nil
+	}
+	if vc.count > 0 {
+		return ErrVolumeInUse
+	}
+	vc.count++
+	return nil
+}
+
 // Decrement decrements the usage count of the passed in volume by 1
 func (s *VolumeStore) Decrement(v volume.Volume) {
 	s.mu.Lock()
@@ -149,10 +262,20 @@ func (s *VolumeStore) Count(v volume.Volume) uint {
 	return vc.count
 }
 
-// List returns all the available volumes
+// List returns all the available volumes, including the ones the volume
+// drivers know about that are not in the store yet, such as the volumes
+// created by other hosts using the same volume plugin.
 func (s *VolumeStore) List() []volume.Volume {
+	discovered := listDriverVolumes()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	for _, v := range discovered {
+		if _, exists := s.vols[v.Name()]; !exists {
+			s.vols[v.Name()] = &volumeCounter{v, 0}
+		}
+	}
+
 	var ls []volume.Volume
 	for _, vc := range s.vols {
 		ls = append(ls, vc.Volume)
@@ -187,3 +310,39 @@ func (s *VolumeStore) filter(f filterFunc) []volume.Volume {
 	}
 	return ls
 }
+
+// listDriverVolumes returns the volumes known to all the volume drivers.
+// Drivers failing to list their volumes are skipped.
+func listDriverVolumes() []volume.Volume {
+	drivers, err := volumedrivers.GetAllDrivers()
+	if err != nil {
+		logrus.Errorf("Error listing volume drivers: %v", err)
+		return nil
+	}
+
+	var ls []volume.Volume
+	for _, d := range drivers {
+		vols, err := d.List()
+		if err != nil {
+			logrus.Errorf("Error listing the volumes of volume driver %s: %v", d.Name(), err)
+			continue
+		}
+		ls = append(ls, vols...)
+	}
+	return ls
+}
+
+// getDriverVolume looks up the volume with the given name in all the volume
+// drivers.
+func getDriverVolume(name string) (volume.Volume, error) {
+	drivers, err := volumedrivers.GetAllDrivers()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range drivers {
+		if v, err := d.Get(name); err == nil {
+			return v, nil
+		}
+	}
+	return nil, ErrNoSuchVolume
+}
@@ -1,6 +1,9 @@
 package store
 
 import (
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
 
 	"github.com/sara-nl/docker-1.9.1/volume"
@@ -10,7 +13,10 @@ import (
 
 func TestList(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	s.AddAll([]volume.Volume{vt.NewFakeVolume("fake1"), vt.NewFakeVolume("fake2")})
 	l := s.List()
 	if len(l) != 2 {
@@ -20,7 +26,10 @@ func TestList(t *testing.T) {
 
 func TestGet(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	s.AddAll([]volume.Volume{vt.NewFakeVolume("fake1"), vt.NewFakeVolume("fake2")})
 	v, err := s.Get("fake1")
 	if err != nil {
@@ -37,8 +46,11 @@ func TestGet(t *testing.T) {
 
 func TestCreate(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
-	v, err := s.Create("fake1", "fake", nil)
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Create("fake1", "fake", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,11 +61,11 @@ func TestCreate(t *testing.T) {
 		t.Fatalf("Expected 1 volume in the store, got %v: %v", len(l), l)
 	}
 
-	if _, err := s.Create("none", "none", nil); err == nil {
+	if _, err := s.Create("none", "none", nil, nil); err == nil {
 		t.Fatalf("Expected unknown driver error, got nil")
 	}
 
-	_, err = s.Create("fakeError", "fake", map[string]string{"error": "create error"})
+	_, err = s.Create("fakeError", "fake", map[string]string{"error": "create error"}, nil)
 	if err == nil || err.Error() != "create error" {
 		t.Fatalf("Expected create error, got %v", err)
 	}
@@ -61,11 +73,14 @@ func TestCreate(t *testing.T) {
 
 func TestRemove(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	if err := s.Remove(vt.NoopVolume{}); err != ErrNoSuchVolume {
 		t.Fatalf("Expected ErrNoSuchVolume error, got %v", err)
 	}
-	v, err := s.Create("fake1", "fake", nil)
+	v, err := s.Create("fake1", "fake", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,7 +98,10 @@ func TestRemove(t *testing.T) {
 }
 
 func TestIncrement(t *testing.T) {
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	v := vt.NewFakeVolume("fake1")
 	s.Increment(v)
 	if l := s.List(); len(l) != 1 {
@@ -109,7 +127,10 @@ func TestIncrement(t *testing.T) {
 }
 
 func TestDecrement(t *testing.T) {
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	v := vt.NoopVolume{}
 	s.Decrement(v)
 	if c := s.Count(v); c != 0 {
@@ -136,7 +157,10 @@ func TestDecrement(t *testing.T) {
 }
 
 func TestFilterByDriver(t *testing.T) {
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	s.Increment(vt.NewFakeVolume("fake1"))
 	s.Increment(vt.NewFakeVolume("fake2"))
@@ -150,3 +174,50 @@ func TestFilterByDriver(t *testing.T) {
 		t.Fatalf("Expected 1 volume, got %v, %v", len(l), l)
 	}
 }
+
+func TestLabels(t *testing.T) {
+	volumedrivers.Register(vt.FakeDriver{}, "fake")
+	root, err := ioutil.TempDir("", "volume-store-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{"project": "tardis"}
+	v, err := s.Create("fake1", "fake", nil, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := s.Labels(v); !reflect.DeepEqual(l, labels) {
+		t.Fatalf("Expected labels %v, got %v", labels, l)
+	}
+
+	// the labels of an existing volume are not changed
+	if _, err := s.Create("fake1", "fake", nil, map[string]string{"project": "other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddAll([]volume.Volume{v})
+	if l := s.Labels(v); !reflect.DeepEqual(l, labels) {
+		t.Fatalf("Expected labels %v after reload, got %v", labels, l)
+	}
+
+	if err := s.Remove(v); err != nil {
+		t.Fatal(err)
+	}
+	s, err = New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := s.Labels(v); len(l) != 0 {
+		t.Fatalf("Expected no labels for a removed volume, got %v", l)
+	}
+}
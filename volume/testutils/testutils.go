@@ -19,10 +19,10 @@ func (NoopVolume) DriverName() string { return "noop" }
 func (NoopVolume) Path() string { return "noop" }
 
 // Mount mounts the volume in the container
-func (NoopVolume) Mount() (string, error) { return "noop", nil }
+func (NoopVolume) Mount(id, containerID string) (string, error) { return "noop", nil }
 
 // Unmount unmounts the volume from the container
-func (NoopVolume) Unmount() error { return nil }
+func (NoopVolume) Unmount(id, containerID string) error { return nil }
 
 // FakeVolume is a fake volume with a random name
 type FakeVolume struct {
@@ -44,10 +44,10 @@ func (FakeVolume) DriverName() string { return "fake" }
 func (FakeVolume) Path() string { return "fake" }
 
 // Mount mounts the volume in the container
-func (FakeVolume) Mount() (string, error) { return "fake", nil }
+func (FakeVolume) Mount(id, containerID string) (string, error) { return "fake", nil }
 
 // Unmount unmounts the volume from the container
-func (FakeVolume) Unmount() error { return nil }
+func (FakeVolume) Unmount(id, containerID string) error { return nil }
 
 // FakeDriver is a driver that generates fake volumes
 type FakeDriver struct{}
@@ -66,3 +66,14 @@ func (FakeDriver) Create(name string, opts map[string]string) (volume.Volume, er
 
 // Remove deletes a volume.
 func (FakeDriver) Remove(v volume.Volume) error { return nil }
+
+// List lists the volumes
+func (FakeDriver) List() ([]volume.Volume, error) { return nil, nil }
+
+// Get gets the volume
+func (FakeDriver) Get(name string) (volume.Volume, error) {
+	return nil, fmt.Errorf("no such volume %s", name)
+}
+
+// Scope returns the local scope
+func (FakeDriver) Scope() string { return volume.LocalScope }
@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"regexp"
 	"strings"
 )
 
@@ -8,6 +9,16 @@ import (
 // implemented in the local package.
 const DefaultDriverName string = "local"
 
+// Scopes of volume drivers.
+const (
+	// LocalScope means the volumes of the driver only exist on the host
+	// they were created on.
+	LocalScope = "local"
+	// GlobalScope means a volume name refers to the same volume on every
+	// host using the driver.
+	GlobalScope = "global"
+)
+
 // Driver is for creating and removing volumes.
 type Driver interface {
 	// Name returns the name of the volume driver.
@@ -16,6 +27,80 @@ type Driver interface {
 	Create(name string, opts map[string]string) (Volume, error)
 	// Remove deletes the volume.
 	Remove(Volume) error
+	// List lists all the volumes known to the driver.
+	List() ([]Volume, error)
+	// Get returns the volume with the given name.
+	Get(name string) (Volume, error)
+	// Scope returns the scope of the driver, LocalScope or GlobalScope.
+	Scope() string
+}
+
+// DeviceMounter is implemented by volumes that are mounted into containers
+// as a block device or network filesystem, rather than bind mounted from the
+// path returned by Mount.
+type DeviceMounter interface {
+	// MountType returns the filesystem type to mount the source returned by
+	// Mount with, "auto" to detect it, and the mount options to use. An
+	// empty filesystem type means the source is bind mounted.
+	MountType() (fsType string, options string)
+}
+
+// AccessLocker is implemented by volumes that can be mounted read-write by
+// a single consumer only, or read-only by many, such as volumes backed by a
+// block device with a filesystem that is not cluster aware.
+type AccessLocker interface {
+	// LockAccess grants the mount with the given id, made for the given
+	// container, read-write access to the volume if rw is set, and
+	// read-only access otherwise. Mounts made for the same container
+	// share its access. A mount may be locked more than once, and is
+	// released once every lock is undone.
+	LockAccess(id, containerID string, rw bool) error
+	// UnlockAccess releases one lock of the mount with the given id.
+	UnlockAccess(id string) error
+}
+
+// SnapshotOpt is the driver option, in the form VOLUME@SNAPSHOT, used to
+// create a volume from a snapshot with the driver of a Snapshotter.
+const SnapshotOpt = "snapshot"
+
+// Snapshot is a point-in-time copy of a volume.
+type Snapshot struct {
+	// Name is the name of the snapshot, unique for the volume.
+	Name string
+	// Size is the size of the volume when the snapshot was taken, in bytes.
+	Size int64
+}
+
+// Snapshotter is implemented by volumes whose driver can take snapshots of
+// them. Such a driver creates a volume from a snapshot when it is given the
+// SnapshotOpt option.
+type Snapshotter interface {
+	// CreateSnapshot takes a snapshot of the volume with the given name.
+	CreateSnapshot(name string) error
+	// ListSnapshots returns the snapshots of the volume.
+	ListSnapshots() ([]Snapshot, error)
+	// RemoveSnapshot removes the snapshot with the given name.
+	RemoveSnapshot(name string) error
+}
+
+// UsageReporter is implemented by volumes that can report how much space
+// they use.
+type UsageReporter interface {
+	// Usage returns the number of bytes used by the volume.
+	Usage() (int64, error)
+}
+
+// CapacityReporter is implemented by volumes that have a fixed capacity.
+type CapacityReporter interface {
+	// Capacity returns the size of the volume, in bytes.
+	Capacity() (int64, error)
+}
+
+// Resizer is implemented by volumes that can be grown while they are in
+// use.
+type Resizer interface {
+	// Resize grows the volume to the given size, in bytes.
+	Resize(size int64) error
 }
 
 // Volume is a place to store data. It is backed by a specific driver, and can be mounted.
@@ -27,10 +112,13 @@ type Volume interface {
 	// Path returns the absolute path to the volume.
 	Path() string
 	// Mount mounts the volume and returns the absolute path to
-	// where it can be consumed.
-	Mount() (string, error)
-	// Unmount unmounts the volume when it is no longer in use.
-	Unmount() error
+	// where it can be consumed. The id identifies this mount and is
+	// passed again to Unmount, containerID is the container the volume is
+	// mounted for, or empty when the daemon mounts the volume itself.
+	Mount(id, containerID string) (string, error)
+	// Unmount unmounts the volume mounted with the given id when it is no
+	// longer in use.
+	Unmount(id, containerID string) error
 }
 
 // read-write modes
@@ -53,12 +141,20 @@ var roModes = map[string]bool{
 	"Z,ro": true,
 }
 
-// mount types
-var mountTypes = map[string]bool{
-	"nfs": true,
+// mountTypePrefix prefixes the name of the volume driver to use when it is
+// given in a mount mode, e.g. "rw,driver=ceph".
+const mountTypePrefix = "driver="
+
+// mountTypeAliases are the volume drivers that can be named in a mount mode
+// without the prefix, e.g. "rw,ceph", kept for compatibility.
+var mountTypeAliases = map[string]bool{
 	"ceph": true,
+	"nfs":  true,
 }
 
+// driverNameRegex matches the valid volume driver names.
+var driverNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
 // ValidMountMode will make sure the mount mode is valid.
 // returns if it's a valid mount mode or not.
 func ValidMountMode(mode string) bool {
@@ -71,7 +167,8 @@ func ReadWrite(mode string) bool {
 }
 
 // ValidMountTypeAndMode checks if the type and mode is valid or not.
-// Valid type and mode is a join between a type (ceph, nfs) and a mode.
+// Valid type and mode is a join between an optional volume type, which
+// names the volume driver to use (e.g. driver=ceph), and a mode.
 func ValidMountTypeAndMode(typeAndMode string) bool {
 	var types = 0
 	var modes = 0
@@ -79,13 +176,26 @@ func ValidMountTypeAndMode(typeAndMode string) bool {
 	for _, item := range strings.Split(typeAndMode, ",") {
 		if item == "rw" || item == "ro" {
 			modes++
-		} else if mountTypes[item] {
-			types++
 		} else if strings.ToLower(item) == "z" {
 			z++
+		} else if _, ok := ParseMountType(item); ok {
+			types++
 		} else {
 			return false
 		}
 	}
 	return modes <= 1 && types <= 1 && z <= 1
 }
+
+// ParseMountType returns the name of the volume driver given by a mount
+// mode item, and whether the item is a volume type at all.
+func ParseMountType(item string) (string, bool) {
+	if mountTypeAliases[item] {
+		return item, true
+	}
+	if !strings.HasPrefix(item, mountTypePrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(item, mountTypePrefix)
+	return name, driverNameRegex.MatchString(name)
+}